@@ -0,0 +1,91 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+
+	"github.com/shihanng/bgist/gist"
+	"github.com/spf13/pflag"
+)
+
+var (
+	author    string
+	committer string
+)
+
+// identityOptions resolves the author of the commits: --author, else the
+// primary verified email of the user, else the user's noreply address. The
+// committer is --committer, else the author. The commits are signed with
+// --sign-key. i is the user checked by preflight.
+func identityOptions(ctx context.Context, client *gist.Client, i gist.Identity) ([]gist.GitOption, error) {
+	ops, resolve, err := flagIdentity()
+	if err != nil || !resolve {
+		return ops, err
+	}
+
+	p, err := client.Author(ctx, i)
+	if err != nil {
+		return nil, err
+	}
+	return append(ops, gist.Author(p)), nil
+}
+
+// flagIdentity returns the options given by --sign-key, --committer, and
+// --author, and whether the author is still to be resolved.
+func flagIdentity() ([]gist.GitOption, bool, error) {
+	ops, err := signOptions()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if committer != "" {
+		p, err := gist.ParsePerson(committer)
+		if err != nil {
+			return nil, false, err
+		}
+		ops = append(ops, gist.Committer(p))
+	}
+
+	if author == "" {
+		return ops, true, nil
+	}
+
+	p, err := gist.ParsePerson(author)
+	if err != nil {
+		return nil, false, err
+	}
+	return append(ops, gist.Author(p)), false, nil
+}
+
+func init() {
+	// Identity of the commits, shared by the commands that commit to a gist.
+	identityFlags := pflag.NewFlagSet("identity", pflag.ExitOnError)
+	identityFlags.StringVar(&author, "author", "",
+		`Author of the commit, "Name <email>" (default the GitHub user with the primary verified email)`)
+	identityFlags.StringVar(&committer, "committer", "",
+		`Committer of the commit, "Name <email>" (default the author)`)
+	identityFlags.StringVar(&signKey, "sign-key", "",
+		"Armored OpenPGP private key to sign the commits with")
+	rootCmd.Flags().AddFlagSet(identityFlags)
+	updateCmd.Flags().AddFlagSet(identityFlags)
+	restoreCmd.Flags().AddFlagSet(identityFlags)
+}
@@ -0,0 +1,186 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+var (
+	configFile string
+	profile    string
+)
+
+// configKeys are the flags that can be set from the environment and the
+// configuration file. Flags that only make sense for a single run, like
+// delete's --yes and --dry-run, are left out so that a stray BGIST_YES=1
+// cannot skip a confirmation.
+var configKeys = map[string]bool{
+	"public":          true,
+	"description":     true,
+	"keep-on-failure": true,
+	"rename":          true,
+	"embed":           true,
+	"storage":         true,
+	"disk-threshold":  true,
+	"retries":         true,
+	"retry-backoff":   true,
+	"output":          true,
+	"template":        true,
+	"progress":        true,
+	"verbose":         true,
+	"host":            true,
+	"api-url":         true,
+	"upload-url":      true,
+	"transport":       true,
+	"ssh-key":         true,
+	"ssh-host":        true,
+	"known-hosts":     true,
+	"author":          true,
+	"committer":       true,
+	"sign-key":        true,
+	"message":         true,
+	"commit-per-file": true,
+}
+
+// configurable reports whether the flag name of cmd is set by applyConfig.
+// public and description are the defaults of a new gist, so they are only
+// applied to the root command, which creates it.
+func configurable(cmd *cobra.Command, name string) bool {
+	switch name {
+	case "public", "description":
+		return !cmd.HasParent()
+	}
+	return configKeys[name]
+}
+
+// defaultConfigFile is $XDG_CONFIG_HOME/bgist/config.yaml, where
+// $XDG_CONFIG_HOME defaults to ~/.config.
+func defaultConfigFile() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return "", errors.Wrap(err, "when looking for the home directory")
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "bgist", "config.yaml"), nil
+}
+
+// loadConfig reads the configuration file into viper. A missing default file
+// is not an error.
+func loadConfig() error {
+	path := configFile
+	if path == "" {
+		var err error
+		if path, err = defaultConfigFile(); err != nil {
+			return err
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return nil
+		}
+	}
+
+	viper.SetConfigFile(path)
+	return errors.Wrapf(viper.ReadInConfig(), "when reading %s", path)
+}
+
+// selectProfile picks the profile from --profile, BGIST_PROFILE, or the
+// profile key of the configuration file, in that order.
+func selectProfile(cmd *cobra.Command) error {
+	if !cmd.Flags().Changed("profile") {
+		if v, ok := os.LookupEnv("BGIST_PROFILE"); ok {
+			profile = v
+		} else {
+			profile = viper.GetString("profile")
+		}
+	}
+
+	if profile != "" && !viper.IsSet("profiles."+profile) {
+		return errors.Errorf("profile %q is not in the configuration file", profile)
+	}
+	return nil
+}
+
+// lookup resolves key from the environment (BGIST_<KEY>), the selected
+// profile, then the top level of the configuration file.
+func lookup(key string) (string, bool) {
+	env := "BGIST_" + strings.ToUpper(strings.Replace(key, "-", "_", -1))
+	if v, ok := os.LookupEnv(env); ok {
+		return v, true
+	}
+
+	if profile != "" {
+		if k := "profiles." + profile + "." + key; viper.IsSet(k) {
+			return viper.GetString(k), true
+		}
+	}
+
+	if viper.IsSet(key) {
+		return viper.GetString(key), true
+	}
+
+	return "", false
+}
+
+// applyConfig sets every configurable flag of cmd that is not given on the
+// command line from lookup, so that flags take precedence over the
+// environment, the profile, and the global configuration.
+func applyConfig(cmd *cobra.Command) error {
+	var err error
+
+	cmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if err != nil || f.Changed || !configurable(cmd, f.Name) {
+			return
+		}
+
+		v, ok := lookup(f.Name)
+		if !ok {
+			return
+		}
+
+		if setErr := f.Value.Set(v); setErr != nil {
+			err = errors.Wrapf(setErr, "invalid value %q for %s", v, f.Name)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	accessToken, _ = lookup("github_access_token")
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&configFile, "config", "",
+		"Configuration file (default $XDG_CONFIG_HOME/bgist/config.yaml)")
+	rootCmd.PersistentFlags().StringVar(&profile, "profile", "", "Profile of the configuration file to use")
+}
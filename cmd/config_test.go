@@ -0,0 +1,171 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testConfig = `profile: personal
+output: yaml
+description: global
+yes: true
+profiles:
+  personal:
+    output: json
+    description: personal
+  work:
+    public: true
+    description: work
+`
+
+// configFlags are the flags of a command used to test applyConfig.
+type configFlags struct {
+	description string
+	public      bool
+	output      string
+	yes         bool
+}
+
+func newConfigCmd(f *configFlags) *cobra.Command {
+	cmd := &cobra.Command{Use: "test"}
+	cmd.Flags().StringVar(&profile, "profile", "", "")
+	cmd.Flags().StringVar(&f.description, "description", "", "")
+	cmd.Flags().BoolVar(&f.public, "public", false, "")
+	cmd.Flags().StringVar(&f.output, "output", "text", "")
+	cmd.Flags().BoolVar(&f.yes, "yes", false, "")
+	return cmd
+}
+
+func TestApplyConfig(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bgist-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(testConfig), 0600))
+
+	configFile = path
+	defer func() {
+		configFile, profile, accessToken = "", "", ""
+		viper.Reset()
+	}()
+
+	for _, tc := range []struct {
+		name     string
+		args     []string
+		env      map[string]string
+		child    bool
+		expected configFlags
+		err      bool
+	}{
+		{
+			name:     "profile of the file over the top level",
+			expected: configFlags{description: "personal", output: "json"},
+		},
+		{
+			name:     "profile from the environment",
+			env:      map[string]string{"BGIST_PROFILE": "work"},
+			expected: configFlags{description: "work", public: true, output: "yaml"},
+		},
+		{
+			name:     "profile flag over the environment",
+			args:     []string{"--profile", "work"},
+			env:      map[string]string{"BGIST_PROFILE": "personal"},
+			expected: configFlags{description: "work", public: true, output: "yaml"},
+		},
+		{
+			name:     "environment over the profile",
+			env:      map[string]string{"BGIST_OUTPUT": "text", "BGIST_DESCRIPTION": "env"},
+			expected: configFlags{description: "env", output: "text"},
+		},
+		{
+			name:     "flag over the environment",
+			args:     []string{"--output", "template", "--public=false"},
+			env:      map[string]string{"BGIST_OUTPUT": "text", "BGIST_PROFILE": "work"},
+			expected: configFlags{description: "work", output: "template"},
+		},
+		{
+			name:     "confirmations are not configurable",
+			env:      map[string]string{"BGIST_YES": "true"},
+			expected: configFlags{description: "personal", output: "json"},
+		},
+		{
+			name:     "public and description only apply to new gists",
+			args:     []string{"--profile", "work"},
+			child:    true,
+			expected: configFlags{output: "yaml"},
+		},
+		{
+			name: "unknown profile",
+			args: []string{"--profile", "unknown"},
+			err:  true,
+		},
+		{
+			name: "unknown profile from the environment",
+			env:  map[string]string{"BGIST_PROFILE": "unknown"},
+			err:  true,
+		},
+		{
+			name: "invalid value",
+			env:  map[string]string{"BGIST_PUBLIC": "maybe"},
+			err:  true,
+		},
+	} {
+		viper.Reset()
+		profile = ""
+		for k, v := range tc.env {
+			require.NoError(t, os.Setenv(k, v))
+		}
+
+		var actual configFlags
+		cmd := newConfigCmd(&actual)
+		if tc.child {
+			(&cobra.Command{Use: "parent"}).AddCommand(cmd)
+		}
+
+		require.NoError(t, cmd.ParseFlags(tc.args), tc.name)
+		require.NoError(t, loadConfig(), tc.name)
+
+		err := selectProfile(cmd)
+		if err == nil {
+			err = applyConfig(cmd)
+		}
+
+		for k := range tc.env {
+			os.Unsetenv(k)
+		}
+
+		if tc.err {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.expected, actual, tc.name)
+	}
+}
@@ -0,0 +1,240 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+)
+
+var verbose bool
+
+// credentialTimeout bounds git credential fill and token_command.
+const credentialTimeout = 30 * time.Second
+
+// credentialSource looks up the access token. An empty token without error
+// means that the source has nothing to offer.
+type credentialSource struct {
+	name  string
+	token func() (string, error)
+}
+
+var credentialSources = []credentialSource{
+	{"git credential fill", gitCredentialToken},
+	{"token file", fileToken},
+	{"token_command", commandToken},
+	{"netrc", netrcToken},
+}
+
+// resolveAccessToken sets accessToken from the first credential source that
+// has one unless it is already given by github_access_token.
+func resolveAccessToken() error {
+	source := "configuration file"
+	if _, ok := os.LookupEnv("BGIST_GITHUB_ACCESS_TOKEN"); ok {
+		source = "environment variable BGIST_GITHUB_ACCESS_TOKEN"
+	}
+
+	for _, s := range credentialSources {
+		if accessToken != "" {
+			break
+		}
+
+		token, err := s.token()
+		if err != nil {
+			return errors.Wrapf(err, "when reading the access token from %s", s.name)
+		}
+		accessToken, source = token, s.name
+	}
+
+	if verbose && accessToken != "" {
+		fmt.Fprintf(os.Stderr, "Using the GitHub access token from %s\n", source)
+	}
+	return nil
+}
+
+// gitCredentialToken asks the git credential helpers for the password of the
+// host. Failures, e.g. git not being installed, are not errors because git
+// is only one of the sources.
+func gitCredentialToken() (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialTimeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, "git", "credential", "fill")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("protocol=https\nhost=%s\n\n", hostname()))
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", nil
+	}
+
+	s := bufio.NewScanner(bytes.NewReader(out))
+	for s.Scan() {
+		if v := strings.TrimPrefix(s.Text(), "password="); v != s.Text() {
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+// fileToken reads the token from token_file, by default token next to the
+// configuration file. The file must not be accessible by others.
+func fileToken() (string, error) {
+	path, ok := lookup("token_file")
+	if !ok {
+		config, err := defaultConfigFile()
+		if err != nil {
+			return "", err
+		}
+		path = filepath.Join(filepath.Dir(config), "token")
+	}
+
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "when expanding %s", path)
+	}
+
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) && !ok {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "when reading %s", path)
+	}
+
+	if runtime.GOOS != "windows" && fi.Mode().Perm()&0077 != 0 {
+		return "", errors.Errorf("%s is accessible by other users, restrict it with chmod 600", path)
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", errors.Wrapf(err, "when reading %s", path)
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// commandToken runs token_command with the shell and uses its stdout, e.g.
+// "pass show github/gist".
+func commandToken() (string, error) {
+	command, ok := lookup("token_command")
+	if !ok || command == "" {
+		return "", nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), credentialTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Stderr = os.Stderr
+
+	out, err := cmd.Output()
+	if err != nil {
+		return "", errors.Wrapf(err, "when running %q", command)
+	}
+
+	token := strings.TrimSpace(string(out))
+	if token == "" {
+		return "", errors.Errorf("%q printed nothing", command)
+	}
+	return token, nil
+}
+
+// netrcToken returns the password of the host, or of its API host, in
+// $NETRC or ~/.netrc.
+func netrcToken() (string, error) {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := homedir.Dir()
+		if err != nil {
+			return "", nil
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+
+	b, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", errors.Wrapf(err, "when reading %s", path)
+	}
+
+	passwords := parseNetrc(string(b))
+	for _, machine := range []string{hostname(), "api." + hostname()} {
+		if p, ok := passwords[machine]; ok {
+			return p, nil
+		}
+	}
+	return "", nil
+}
+
+// parseNetrc returns the passwords by machine name. The entry of default
+// matches no machine. Macro definitions are not supported.
+func parseNetrc(content string) map[string]string {
+	passwords := make(map[string]string)
+
+	var machine string
+	fields := strings.Fields(content)
+	for i := 0; i < len(fields); i++ {
+		switch fields[i] {
+		case "machine":
+			if i+1 < len(fields) {
+				i++
+				machine = fields[i]
+			}
+		case "default":
+			machine = ""
+		case "login", "account":
+			// Skip the value, which might read like a keyword.
+			i++
+		case "password":
+			if i+1 < len(fields) {
+				i++
+				if machine != "" {
+					passwords[machine] = fields[i]
+				}
+			}
+		}
+	}
+	return passwords
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "v", false,
+		"Report where the GitHub access token is read from")
+}
@@ -0,0 +1,114 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseNetrc(t *testing.T) {
+	for _, tc := range []struct {
+		content  string
+		expected map[string]string
+	}{
+		{
+			content:  "machine github.com login johndoe password secret",
+			expected: map[string]string{"github.com": "secret"},
+		},
+		{
+			content:  "machine github.com password secret login johndoe",
+			expected: map[string]string{"github.com": "secret"},
+		},
+		{
+			content: `machine github.com
+	login johndoe
+	password secret
+
+machine api.github.example.com
+	password other
+	login janedoe`,
+			expected: map[string]string{"github.com": "secret", "api.github.example.com": "other"},
+		},
+		{
+			content:  "machine github.com login password password secret",
+			expected: map[string]string{"github.com": "secret"},
+		},
+		{
+			content:  "machine github.com login johndoe account gist password secret",
+			expected: map[string]string{"github.com": "secret"},
+		},
+		{
+			content:  "machine github.com login johndoe default login anonymous password guest",
+			expected: map[string]string{},
+		},
+		{
+			content:  "default password guest machine github.com password secret",
+			expected: map[string]string{"github.com": "secret"},
+		},
+		{
+			content:  "machine github.com login johndoe password",
+			expected: map[string]string{},
+		},
+		{
+			content:  "",
+			expected: map[string]string{},
+		},
+	} {
+		assert.Equal(t, tc.expected, parseNetrc(tc.content), tc.content)
+	}
+}
+
+func TestFileToken(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file permissions are not checked on Windows")
+	}
+
+	dir, err := ioutil.TempDir("", "bgist-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "token")
+	require.NoError(t, ioutil.WriteFile(path, []byte("secret\n"), 0600))
+
+	require.NoError(t, os.Setenv("BGIST_TOKEN_FILE", path))
+	defer os.Unsetenv("BGIST_TOKEN_FILE")
+
+	token, err := fileToken()
+	assert.NoError(t, err)
+	assert.Equal(t, "secret", token)
+
+	for _, mode := range []os.FileMode{0640, 0604, 0660, 0644} {
+		require.NoError(t, os.Chmod(path, mode))
+		_, err := fileToken()
+		assert.Error(t, err, "%o", mode)
+	}
+
+	// An explicit token_file must exist.
+	require.NoError(t, os.Setenv("BGIST_TOKEN_FILE", filepath.Join(dir, "missing")))
+	_, err = fileToken()
+	assert.Error(t, err)
+}
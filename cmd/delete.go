@@ -0,0 +1,167 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+//go:generate mockgen -source=delete.go -destination=mock_delete_test.go -package=cmd
+
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shihanng/bgist/gist"
+	"github.com/spf13/cobra"
+)
+
+var (
+	deleteYes    bool
+	deleteDryRun bool
+)
+
+// deleteCmd represents the delete command
+var deleteCmd = &cobra.Command{
+	Use:     "delete <gist-id|gist-url>...",
+	Example: "BGIST_GITHUB_ACCESS_TOKEN=secret bgist delete abc123 https://gist.github.com/johndoe/def456",
+	Short:   "Delete gists.",
+	Long: `Delete gists.
+
+The description and the files of every gist are shown before asking for
+confirmation. A failure does not stop the remaining gists from being deleted.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: deleteGists,
+}
+
+func deleteGists(cmd *cobra.Command, args []string) error {
+	if err := checkAccessToken(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := preflight(ctx, client); err != nil {
+		return err
+	}
+
+	return deleteAll(ctx, client, bufio.NewReader(os.Stdin), os.Stdout, args)
+}
+
+// deleter is the part of gist.Client used by delete.
+type deleter interface {
+	GetGist(ctx context.Context, id string) (gist.Info, error)
+	DeleteGist(ctx context.Context, id string) error
+}
+
+// deleteAll deletes the gists of args, asking on stdin unless --yes is
+// given, and writes a summary to w. A failure does not stop the remaining
+// gists from being deleted.
+func deleteAll(ctx context.Context, client deleter, stdin *bufio.Reader, w io.Writer, args []string) error {
+	var deleted, skipped, failed int
+
+	for _, arg := range args {
+		if err := deleteGist(ctx, client, stdin, w, arg); err == errSkipped {
+			skipped++
+		} else if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not delete %s: %v\n", arg, err)
+			failed++
+		} else {
+			deleted++
+		}
+	}
+
+	verb := "Deleted"
+	if deleteDryRun {
+		verb = "Would delete"
+	}
+	fmt.Fprintf(w, "%s %d, skipped %d, failed %d\n", verb, deleted, skipped, failed)
+
+	if failed > 0 {
+		return errors.Errorf("%d gist(s) could not be deleted", failed)
+	}
+	return nil
+}
+
+var errSkipped = errors.New("skipped")
+
+func deleteGist(ctx context.Context, client deleter, stdin *bufio.Reader, w io.Writer, arg string) error {
+	id, err := gist.ParseID(arg)
+	if err != nil {
+		return err
+	}
+
+	info, err := client.GetGist(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "%s %s\n", info.ID, info.Description)
+	for _, f := range info.Files {
+		fmt.Fprintf(w, "    %s\n", f.Name)
+	}
+
+	if deleteDryRun {
+		return nil
+	}
+
+	if !deleteYes {
+		ok, err := confirm(stdin, fmt.Sprintf("Delete gist %s?", info.ID))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errSkipped
+		}
+	}
+
+	return client.DeleteGist(ctx, info.ID)
+}
+
+// confirm asks question on stderr and reads the answer from r.
+func confirm(r *bufio.Reader, question string) (bool, error) {
+	fmt.Fprintf(os.Stderr, "%s [y/N] ", question)
+
+	answer, err := r.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return false, errors.Wrap(err, "when reading the answer")
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+func init() {
+	deleteCmd.Flags().BoolVarP(&deleteYes, "yes", "y", false, "Delete without asking for confirmation")
+	deleteCmd.Flags().BoolVar(&deleteDryRun, "dry-run", false, "Only show what would be deleted")
+
+	rootCmd.AddCommand(deleteCmd)
+}
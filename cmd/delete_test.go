@@ -0,0 +1,132 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/pkg/errors"
+	"github.com/shihanng/bgist/gist"
+	"github.com/stretchr/testify/assert"
+)
+
+func testGist(id string) gist.Info {
+	return gist.Info{
+		ID:          id,
+		Description: "design review",
+		Files:       []gist.FileInfo{{Name: "logo.png"}},
+	}
+}
+
+func TestDeleteAll(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockDeleter := NewMockdeleter(mockCtrl)
+	ctx := context.Background()
+
+	for _, id := range []string{"a1", "b2", "c3", "d4"} {
+		mockDeleter.EXPECT().GetGist(ctx, id).Return(testGist(id), nil)
+	}
+	mockDeleter.EXPECT().DeleteGist(ctx, "a1").Return(nil)
+	mockDeleter.EXPECT().DeleteGist(ctx, "b2").Return(nil)
+
+	stdin := bufio.NewReader(strings.NewReader("y\nYes\nno\n"))
+	var out bytes.Buffer
+
+	// The last answer is missing, which means no.
+	err := deleteAll(ctx, mockDeleter, stdin, &out, []string{
+		"a1", "https://gist.github.com/johndoe/b2", "c3", "d4",
+	})
+	assert.NoError(t, err)
+	assert.Contains(t, out.String(), "d4 design review\n    logo.png\n")
+	assert.Contains(t, out.String(), "Deleted 2, skipped 2, failed 0\n")
+}
+
+func TestDeleteAllContinuesAfterFailure(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockDeleter := NewMockdeleter(mockCtrl)
+	ctx := context.Background()
+
+	deleteYes = true
+	defer func() { deleteYes = false }()
+
+	gomock.InOrder(
+		mockDeleter.EXPECT().GetGist(ctx, "a1").Return(gist.Info{}, errors.New("not found")),
+		mockDeleter.EXPECT().GetGist(ctx, "b2").Return(testGist("b2"), nil),
+		mockDeleter.EXPECT().DeleteGist(ctx, "b2").Return(errors.New("forbidden")),
+		mockDeleter.EXPECT().GetGist(ctx, "c3").Return(testGist("c3"), nil),
+		mockDeleter.EXPECT().DeleteGist(ctx, "c3").Return(nil),
+	)
+
+	var out bytes.Buffer
+	err := deleteAll(ctx, mockDeleter, bufio.NewReader(strings.NewReader("")), &out,
+		[]string{"a1", "b2", "not a gist", "c3"})
+	assert.EqualError(t, err, "3 gist(s) could not be deleted")
+	assert.Contains(t, out.String(), "Deleted 1, skipped 0, failed 3\n")
+}
+
+func TestDeleteAllDryRun(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockDeleter := NewMockdeleter(mockCtrl)
+	ctx := context.Background()
+
+	deleteDryRun = true
+	defer func() { deleteDryRun = false }()
+
+	// Nothing is asked for or deleted.
+	mockDeleter.EXPECT().GetGist(ctx, "a1").Return(testGist("a1"), nil)
+
+	var out bytes.Buffer
+	err := deleteAll(ctx, mockDeleter, bufio.NewReader(strings.NewReader("")), &out, []string{"a1"})
+	assert.NoError(t, err)
+	assert.Equal(t, "a1 design review\n    logo.png\nWould delete 1, skipped 0, failed 0\n", out.String())
+}
+
+func TestConfirm(t *testing.T) {
+	for _, tc := range []struct {
+		answer   string
+		expected bool
+	}{
+		{"y\n", true},
+		{"Y\n", true},
+		{"yes\n", true},
+		{" YES \n", true},
+		{"yes", true},
+		{"n\n", false},
+		{"no\n", false},
+		{"yep\n", false},
+		{"\n", false},
+		{"", false},
+	} {
+		ok, err := confirm(bufio.NewReader(strings.NewReader(tc.answer)), "Delete?")
+		assert.NoError(t, err, tc.answer)
+		assert.Equal(t, tc.expected, ok, tc.answer)
+	}
+}
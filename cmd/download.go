@@ -0,0 +1,109 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+)
+
+var (
+	downloadDir string
+	downloadCat bool
+)
+
+// downloadCmd represents the download command
+var downloadCmd = &cobra.Command{
+	Use: "download <gist-id|gist-url> [file]...",
+	Example: `bgist download abc123 -C screenshots
+bgist download --cat abc123 photo-1.png > photo-1.png`,
+	Short: "Download the files of a gist.",
+	Long: `Download the files of a gist.
+
+The gist is cloned with git so that binary files are kept byte-for-byte. All
+files are downloaded when none is given. Flattened files are written to their
+original nested path recorded in .bgist-manifest.json.
+
+With --cat a single file is written to stdout instead.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: download,
+}
+
+func download(cmd *cobra.Command, args []string) error {
+	if err := checkAccessToken(); err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, g, err := clone(ctx, client, args[0])
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+
+	var filenames []string
+	for _, name := range args[1:] {
+		filename, err := g.Resolve(name)
+		if err != nil {
+			return err
+		}
+		filenames = append(filenames, filename)
+	}
+
+	if !downloadCat {
+		return g.Download(downloadDir, filenames...)
+	}
+
+	if len(filenames) == 0 {
+		if filenames, err = g.Filenames(); err != nil {
+			return err
+		}
+	}
+	if len(filenames) != 1 {
+		return errors.New("--cat needs exactly one file")
+	}
+
+	f, err := g.Open(filenames[0])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	_, err = io.Copy(os.Stdout, f)
+	return errors.Wrap(err, "when writing to stdout")
+}
+
+func init() {
+	downloadCmd.Flags().StringVarP(&downloadDir, "dir", "C", ".", "Directory the files are written to")
+	downloadCmd.Flags().BoolVar(&downloadCat, "cat", false, "Write a single file to stdout")
+
+	rootCmd.AddCommand(downloadCmd)
+}
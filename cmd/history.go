@@ -0,0 +1,280 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shihanng/bgist/gist"
+	"github.com/spf13/cobra"
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:     "history <gist-id|gist-url>",
+	Example: "BGIST_GITHUB_ACCESS_TOKEN=secret bgist history abc123",
+	Short:   "List the revisions of a gist.",
+	Long: `List the revisions of a gist.
+
+Every revision is shown with its author, date, and changed files. The history
+is read from the cloned gist so that it is complete, unlike the API's.`,
+	Args: cobra.ExactArgs(1),
+	RunE: history,
+}
+
+// showCmd represents the show command
+var showCmd = &cobra.Command{
+	Use:     "show <gist-id|gist-url>@<revision>",
+	Example: "BGIST_GITHUB_ACCESS_TOKEN=secret bgist show abc123@0123abc",
+	Short:   "Show a revision of a gist and its files.",
+	Args:    cobra.ExactArgs(1),
+	RunE:    show,
+}
+
+// restoreCmd represents the restore command
+var restoreCmd = &cobra.Command{
+	Use:     "restore <gist-id|gist-url> <revision>",
+	Example: "BGIST_GITHUB_ACCESS_TOKEN=secret bgist restore abc123 0123abc",
+	Short:   "Restore the files of a gist to a revision.",
+	Long: `Restore the files of a gist to a revision.
+
+The files are restored as a new revision, the history is kept.`,
+	Args: cobra.ExactArgs(2),
+	RunE: restore,
+}
+
+type revisionResult struct {
+	Hash    string         `json:"hash" yaml:"hash"`
+	Author  string         `json:"author" yaml:"author"`
+	Email   string         `json:"email" yaml:"email"`
+	Date    time.Time      `json:"date" yaml:"date"`
+	Message string         `json:"message" yaml:"message"`
+	Changes []changeResult `json:"changes" yaml:"changes"`
+	Files   []resultFile   `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+type changeResult struct {
+	Action   string `json:"action" yaml:"action"`
+	Filename string `json:"filename" yaml:"filename"`
+}
+
+func newRevisionResult(rev gist.Revision) revisionResult {
+	r := revisionResult{
+		Hash:    rev.Hash,
+		Author:  rev.Author,
+		Email:   rev.Email,
+		Date:    rev.When,
+		Message: rev.Message,
+		Changes: []changeResult{},
+	}
+
+	for _, c := range rev.Changes {
+		r.Changes = append(r.Changes, changeResult{Action: c.Action, Filename: c.Filename})
+	}
+
+	return r
+}
+
+func history(cmd *cobra.Command, args []string) error {
+	if err := checkAccessToken(); err != nil {
+		return err
+	}
+
+	tmpl, err := checkOutput()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, g, err := clone(ctx, client, args[0])
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+
+	revs, err := g.History()
+	if err != nil {
+		return err
+	}
+
+	rs := []revisionResult{}
+	for _, rev := range revs {
+		rs = append(rs, newRevisionResult(rev))
+	}
+
+	if ok, err := encode(os.Stdout, rs); ok {
+		return err
+	}
+
+	for _, r := range rs {
+		if err := printRevision(os.Stdout, tmpl, r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func show(cmd *cobra.Command, args []string) error {
+	if err := checkAccessToken(); err != nil {
+		return err
+	}
+
+	tmpl, err := checkOutput()
+	if err != nil {
+		return err
+	}
+
+	i := strings.LastIndex(args[0], "@")
+	if i < 0 {
+		return errors.Errorf("%s has no @<revision>", args[0])
+	}
+
+	ctx := context.Background()
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, g, err := clone(ctx, client, args[0][:i])
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+
+	rev, files, err := g.Show(args[0][i+1:])
+	if err != nil {
+		return err
+	}
+
+	r := newRevisionResult(rev)
+	for _, f := range files {
+		r.Files = append(r.Files, resultFile{Name: f.Name, Size: f.Size})
+	}
+
+	if ok, err := encode(os.Stdout, r); ok {
+		return err
+	}
+
+	if err := printRevision(os.Stdout, tmpl, r); err != nil || tmpl != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nFILE\tSIZE")
+	for _, f := range r.Files {
+		fmt.Fprintf(tw, "%s\t%s\n", f.Name, humanBytes(int64(f.Size)))
+	}
+	return errors.Wrap(tw.Flush(), "when writing table output")
+}
+
+// printRevision writes r with the template given by --template or as a short
+// header followed by the changed files.
+func printRevision(w io.Writer, t *template.Template, r revisionResult) error {
+	if t != nil {
+		return errors.Wrap(t.Execute(w, r), "when writing template output")
+	}
+
+	fmt.Fprintf(w, "%.7s  %s  %s  %s\n", r.Hash, r.Date.Local().Format("2006-01-02 15:04"), r.Author, r.Message)
+	for _, c := range r.Changes {
+		fmt.Fprintf(w, "    %-8s  %s\n", c.Action, c.Filename)
+	}
+	return nil
+}
+
+func restore(cmd *cobra.Command, args []string) error {
+	if err := checkAccessToken(); err != nil {
+		return err
+	}
+
+	tmpl, err := checkOutput()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := preflight(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	identity, err := identityOptions(ctx, client, user)
+	if err != nil {
+		return err
+	}
+
+	info, g, err := clone(ctx, client, args[0], identity...)
+	if err != nil {
+		return err
+	}
+	defer g.Close()
+
+	if err := g.Restore(args[1]); err != nil {
+		return err
+	}
+
+	commit, err := g.Commit("restore " + args[1])
+	if err != nil {
+		return err
+	}
+
+	if err := g.Push(); err != nil {
+		return err
+	}
+
+	info, err = client.GetGist(ctx, info.ID)
+	if err != nil {
+		return err
+	}
+
+	r, err := newResult(info.Pin(commit), nil)
+	if err != nil {
+		return err
+	}
+
+	return printResult(os.Stdout, tmpl, "Restored", r)
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(showCmd)
+	rootCmd.AddCommand(restoreCmd)
+}
@@ -0,0 +1,117 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shihanng/bgist/gist"
+)
+
+const defaultHost = "github.com"
+
+var (
+	host      string
+	apiURL    string
+	uploadURL string
+)
+
+// enterpriseURLs returns the API base and upload URLs of a GitHub Enterprise
+// Server, or empty strings for github.com. URLs not given by --api-url and
+// --upload-url are derived from --host.
+func enterpriseURLs() (string, string, error) {
+	h := hostname()
+	if h == defaultHost && apiURL == "" && uploadURL == "" {
+		return "", "", nil
+	}
+
+	base, upload := apiURL, uploadURL
+	if base == "" {
+		base = fmt.Sprintf("https://%s/api/v3/", h)
+	}
+	if upload == "" {
+		upload = fmt.Sprintf("https://%s/api/uploads/", h)
+	}
+
+	for _, v := range []string{base, upload} {
+		u, err := url.Parse(v)
+		if err != nil {
+			return "", "", errors.Wrapf(err, "when parsing GitHub URL %s", v)
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return "", "", errors.Errorf("%s is not an absolute URL", v)
+		}
+	}
+	return base, upload, nil
+}
+
+// hostname is the name of the host given by --host, which may also be a URL,
+// e.g. https://github.example.com/.
+func hostname() string {
+	v := host
+	if !strings.Contains(v, "://") {
+		v = "https://" + v
+	}
+	u, err := url.Parse(v)
+	if err != nil || u.Hostname() == "" {
+		return host
+	}
+	return u.Hostname()
+}
+
+// tokenURL is where a personal access token of the host can be created.
+func tokenURL() string {
+	return fmt.Sprintf("https://%s/settings/tokens", hostname())
+}
+
+func newClient(ctx context.Context) (*gist.Client, error) {
+	base, upload, err := enterpriseURLs()
+	if err != nil {
+		return nil, err
+	}
+
+	var client *gist.Client
+	if base == "" {
+		client = gist.NewClient(ctx, accessToken)
+	} else {
+		client, err = gist.NewEnterpriseClient(ctx, accessToken, base, upload)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	client.Host = hostname()
+	client.Retry = retryPolicy()
+	return client, nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&host, "host", defaultHost,
+		"GitHub host, e.g. github.example.com for GitHub Enterprise Server")
+	rootCmd.PersistentFlags().StringVar(&apiURL, "api-url", "",
+		"API base URL of GitHub Enterprise Server (default https://<host>/api/v3/)")
+	rootCmd.PersistentFlags().StringVar(&uploadURL, "upload-url", "",
+		"Upload URL of GitHub Enterprise Server (default https://<host>/api/uploads/)")
+}
@@ -0,0 +1,106 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHostname(t *testing.T) {
+	defer func() { host = defaultHost }()
+
+	for _, tc := range []struct {
+		host     string
+		expected string
+	}{
+		{host: "github.com", expected: "github.com"},
+		{host: "ghe.local/", expected: "ghe.local"},
+		{host: "https://ghe.local", expected: "ghe.local"},
+		{host: "http://ghe.local", expected: "ghe.local"},
+		{host: "https://ghe.local/some/path", expected: "ghe.local"},
+		{host: "ghe.local:8443", expected: "ghe.local"},
+	} {
+		host = tc.host
+		assert.Equal(t, tc.expected, hostname(), tc.host)
+	}
+}
+
+func TestEnterpriseURLs(t *testing.T) {
+	defer func() { host, apiURL, uploadURL = defaultHost, "", "" }()
+
+	for _, tc := range []struct {
+		name      string
+		host      string
+		apiURL    string
+		uploadURL string
+		base      string
+		upload    string
+		err       bool
+	}{
+		{
+			name: "github.com",
+			host: defaultHost,
+		},
+		{
+			name: "github.com as a URL",
+			host: "https://github.com/",
+		},
+		{
+			name:   "derived from host",
+			host:   "http://ghe.local/",
+			base:   "https://ghe.local/api/v3/",
+			upload: "https://ghe.local/api/uploads/",
+		},
+		{
+			name:      "explicit URLs",
+			host:      defaultHost,
+			apiURL:    "https://api.ghe.local/",
+			uploadURL: "https://uploads.ghe.local/",
+			base:      "https://api.ghe.local/",
+			upload:    "https://uploads.ghe.local/",
+		},
+		{
+			name:   "explicit API URL",
+			host:   "ghe.local",
+			apiURL: "http://ghe.local:8080/api/v3/",
+			base:   "http://ghe.local:8080/api/v3/",
+			upload: "https://ghe.local/api/uploads/",
+		},
+		{
+			name:   "relative URL",
+			host:   "ghe.local",
+			apiURL: "api/v3/",
+			err:    true,
+		},
+	} {
+		host, apiURL, uploadURL = tc.host, tc.apiURL, tc.uploadURL
+
+		base, upload, err := enterpriseURLs()
+		if tc.err {
+			assert.Error(t, err, tc.name)
+			continue
+		}
+		assert.NoError(t, err, tc.name)
+		assert.Equal(t, tc.base, base, tc.name)
+		assert.Equal(t, tc.upload, upload, tc.name)
+	}
+}
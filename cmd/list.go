@@ -0,0 +1,130 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shihanng/bgist/gist"
+	"github.com/spf13/cobra"
+)
+
+var (
+	listSince       string
+	listPublic      bool
+	listSecret      bool
+	listFilename    string
+	listDescription string
+)
+
+// listCmd represents the list command
+var listCmd = &cobra.Command{
+	Use:     "list",
+	Example: "BGIST_GITHUB_ACCESS_TOKEN=secret bgist list --since 168h --filename-contains .png",
+	Short:   "List your gists.",
+	Long: `List your gists.
+
+--since takes a date (2006-01-02), a timestamp (RFC 3339), or a duration
+relative to now, e.g. 168h for the last week.`,
+	Args: cobra.NoArgs,
+	RunE: list,
+}
+
+func list(cmd *cobra.Command, args []string) error {
+	if err := checkAccessToken(); err != nil {
+		return err
+	}
+
+	tmpl, err := checkOutput()
+	if err != nil {
+		return err
+	}
+
+	filter := gist.ListFilter{
+		Filename:    listFilename,
+		Description: listDescription,
+	}
+
+	if listSince != "" {
+		if filter.Since, err = parseSince(listSince, time.Now()); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case listPublic && listSecret:
+		return errors.New("--public and --secret cannot be used together")
+	case listPublic, listSecret:
+		filter.Public = &listPublic
+	}
+
+	ctx := context.Background()
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	infos, err := client.ListGists(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	var rs []result
+	for _, info := range infos {
+		r, err := newResult(info, nil)
+		if err != nil {
+			return err
+		}
+		rs = append(rs, r)
+	}
+
+	return printResults(os.Stdout, tmpl, rs)
+}
+
+func parseSince(v string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", v, time.Local); err == nil {
+		return t, nil
+	}
+
+	if d, err := time.ParseDuration(v); err == nil {
+		return now.Add(-d), nil
+	}
+
+	return time.Time{}, errors.Errorf("invalid --since %q, expected a date, an RFC 3339 timestamp, or a duration", v)
+}
+
+func init() {
+	listCmd.Flags().StringVar(&listSince, "since", "", "Only list gists updated since then")
+	listCmd.Flags().BoolVar(&listPublic, "public", false, "Only list public gists")
+	listCmd.Flags().BoolVar(&listSecret, "secret", false, "Only list secret gists")
+	listCmd.Flags().StringVar(&listFilename, "filename-contains", "", "Only list gists with a filename containing this")
+	listCmd.Flags().StringVar(&listDescription, "description-contains", "", "Only list gists with a description containing this")
+
+	rootCmd.AddCommand(listCmd)
+}
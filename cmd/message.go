@@ -0,0 +1,90 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shihanng/bgist/gist"
+	"github.com/spf13/pflag"
+)
+
+const defaultMessage = `Add {{join .Files ", "}}`
+
+var (
+	message       string
+	commitPerFile bool
+)
+
+// messageData is what --message is executed with.
+type messageData struct {
+	Files []string
+	Count int
+	Date  time.Time
+	Host  string
+}
+
+func messageTemplate() (*template.Template, error) {
+	t, err := template.New("message").
+		Funcs(template.FuncMap{"join": strings.Join}).
+		Parse(message)
+	return t, errors.Wrap(err, "when parsing --message")
+}
+
+// commitMessage executes t for the uploads of a commit made at now.
+func commitMessage(t *template.Template, uploads []gist.Upload, now time.Time) (string, error) {
+	data := messageData{
+		Files: []string{},
+		Count: len(uploads),
+		Date:  now,
+	}
+	for _, u := range uploads {
+		data.Files = append(data.Files, u.Name)
+	}
+	data.Host, _ = os.Hostname()
+
+	var b bytes.Buffer
+	if err := t.Execute(&b, data); err != nil {
+		return "", errors.Wrap(err, "when executing --message")
+	}
+
+	msg := strings.TrimSpace(b.String())
+	if msg == "" {
+		return "", errors.New("--message results in an empty commit message")
+	}
+	return msg, nil
+}
+
+func init() {
+	// Flags shared by the commands that push uploads.
+	messageFlags := pflag.NewFlagSet("message", pflag.ExitOnError)
+	messageFlags.StringVarP(&message, "message", "m", defaultMessage,
+		"Go text/template of the commit message with .Files, .Count, .Date, and .Host")
+	messageFlags.BoolVar(&commitPerFile, "commit-per-file", false,
+		"Make a separate commit for every file")
+	rootCmd.Flags().AddFlagSet(messageFlags)
+	updateCmd.Flags().AddFlagSet(messageFlags)
+}
@@ -0,0 +1,85 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/shihanng/bgist/gist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommitMessage(t *testing.T) {
+	host, err := os.Hostname()
+	require.NoError(t, err)
+
+	defer func() { message = defaultMessage }()
+
+	now := time.Date(2018, 7, 1, 12, 30, 0, 0, time.UTC)
+	uploads := []gist.Upload{{Name: "a.png"}, {Name: "b.png"}}
+
+	for _, tc := range []struct {
+		message  string
+		uploads  []gist.Upload
+		expected string
+		err      bool
+	}{
+		{message: defaultMessage, uploads: uploads, expected: "Add a.png, b.png"},
+		{message: defaultMessage, uploads: uploads[:1], expected: "Add a.png"},
+		{message: "{{.Count}} files", uploads: uploads, expected: "2 files"},
+		{message: "{{index .Files 1}}", uploads: uploads, expected: "b.png"},
+		{message: `Upload of {{.Date.Format "2006-01-02"}}`, uploads: uploads, expected: "Upload of 2018-07-01"},
+		{message: "From {{.Host}}", uploads: uploads, expected: "From " + host},
+		{message: "  Add  \n", uploads: uploads, expected: "Add"},
+		{message: "{{if gt .Count 5}}Add{{end}}", uploads: uploads, err: true},
+		{message: "   ", uploads: uploads, err: true},
+		{message: "{{index .Files 2}}", uploads: uploads, err: true},
+		{message: "{{.Unknown}}", uploads: uploads, err: true},
+	} {
+		message = tc.message
+		tmpl, err := messageTemplate()
+		require.NoError(t, err, tc.message)
+
+		actual, err := commitMessage(tmpl, tc.uploads, now)
+		if tc.err {
+			assert.Error(t, err, tc.message)
+			continue
+		}
+		assert.NoError(t, err, tc.message)
+		assert.Equal(t, tc.expected, actual, tc.message)
+	}
+}
+
+func TestMessageTemplate(t *testing.T) {
+	defer func() { message = defaultMessage }()
+
+	for _, m := range []string{
+		"{{.Files",
+		"{{end}}",
+		"{{unknown .Files}}",
+	} {
+		message = m
+		_, err := messageTemplate()
+		assert.Error(t, err, m)
+	}
+}
@@ -0,0 +1,227 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/shihanng/bgist/gist"
+	yaml "gopkg.in/yaml.v2"
+)
+
+const (
+	outputText     = "text"
+	outputJSON     = "json"
+	outputYAML     = "yaml"
+	outputTemplate = "template"
+)
+
+var (
+	output       string
+	templateText string
+	embed        string
+)
+
+type result struct {
+	ID          string       `json:"id" yaml:"id"`
+	HTMLURL     string       `json:"html_url" yaml:"html_url"`
+	GitURL      string       `json:"git_url" yaml:"git_url"`
+	Owner       string       `json:"owner" yaml:"owner"`
+	OwnerName   string       `json:"owner_name,omitempty" yaml:"owner_name,omitempty"`
+	Description string       `json:"description" yaml:"description"`
+	Public      bool         `json:"public" yaml:"public"`
+	CreatedAt   time.Time    `json:"created_at" yaml:"created_at"`
+	UpdatedAt   time.Time    `json:"updated_at" yaml:"updated_at"`
+	Commit      string       `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Files       []resultFile `json:"files" yaml:"files"`
+}
+
+type resultFile struct {
+	Name   string `json:"name" yaml:"name"`
+	Size   int    `json:"size" yaml:"size"`
+	RawURL string `json:"raw_url" yaml:"raw_url"`
+	Embed  string `json:"embed,omitempty" yaml:"embed,omitempty"`
+}
+
+// newResult converts info for printing. When --embed is given, a snippet
+// pointing at the pinned raw URL is added to every uploaded file.
+func newResult(info gist.Info, uploads []gist.Upload) (result, error) {
+	r := result{
+		ID:          info.ID,
+		HTMLURL:     info.HTMLURL,
+		GitURL:      info.GitURL,
+		Owner:       info.Owner.Login,
+		OwnerName:   info.Owner.Name,
+		Description: info.Description,
+		Public:      info.Public,
+		CreatedAt:   info.CreatedAt,
+		UpdatedAt:   info.UpdatedAt,
+		Commit:      info.Commit,
+		Files:       []resultFile{},
+	}
+
+	uploaded := make(map[string]bool, len(uploads))
+	for _, u := range uploads {
+		uploaded[u.Name] = true
+	}
+
+	for _, f := range info.Files {
+		rf := resultFile{
+			Name:   f.Name,
+			Size:   f.Size,
+			RawURL: f.RawURL,
+		}
+
+		if embed != "" && uploaded[f.Name] {
+			snippet, err := gist.Embed(gist.EmbedFormat(embed), f.Name, f.RawURL)
+			if err != nil {
+				return result{}, err
+			}
+			rf.Embed = snippet
+		}
+
+		r.Files = append(r.Files, rf)
+	}
+
+	return r, nil
+}
+
+// checkOutput validates --output, --template, and --embed before any API call
+// is made.
+func checkOutput() (*template.Template, error) {
+	if embed != "" && !validEmbed(gist.EmbedFormat(embed)) {
+		return nil, errors.Errorf("unknown embed format %q", embed)
+	}
+
+	switch output {
+	case outputText, outputJSON, outputYAML:
+		return nil, nil
+	case outputTemplate:
+		if templateText == "" {
+			return nil, errors.New("--template is required with --output template")
+		}
+		t, err := template.New("output").Parse(templateText)
+		return t, errors.Wrap(err, "when parsing --template")
+	default:
+		return nil, errors.Errorf("unknown output format %q", output)
+	}
+}
+
+func validEmbed(format gist.EmbedFormat) bool {
+	for _, f := range gist.EmbedFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// encode writes v when --output is json or yaml and reports whether it did.
+func encode(w io.Writer, v interface{}) (bool, error) {
+	switch output {
+	case outputJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return true, errors.Wrap(enc.Encode(v), "when writing JSON output")
+	case outputYAML:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return true, errors.Wrap(err, "when writing YAML output")
+		}
+		_, err = w.Write(b)
+		return true, errors.Wrap(err, "when writing YAML output")
+	default:
+		return false, nil
+	}
+}
+
+// printResult writes r in the format selected by --output. The text format is
+// "<action> <HTML URL>" followed by the embed snippets, one per line.
+func printResult(w io.Writer, t *template.Template, action string, r result) error {
+	if ok, err := encode(w, r); ok {
+		return err
+	}
+
+	switch output {
+	case outputTemplate:
+		return errors.Wrap(t.Execute(w, r), "when writing template output")
+	default:
+		if _, err := fmt.Fprintln(w, action, r.HTMLURL); err != nil {
+			return err
+		}
+
+		for _, f := range r.Files {
+			if f.Embed == "" {
+				continue
+			}
+			if _, err := fmt.Fprintln(w, f.Embed); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// printResults writes rs in the format selected by --output. The text format
+// is a table, the template is executed for every gist.
+func printResults(w io.Writer, t *template.Template, rs []result) error {
+	if rs == nil {
+		rs = []result{}
+	}
+
+	if ok, err := encode(w, rs); ok {
+		return err
+	}
+
+	switch output {
+	case outputTemplate:
+		for _, r := range rs {
+			if err := t.Execute(w, r); err != nil {
+				return errors.Wrap(err, "when writing template output")
+			}
+		}
+		return nil
+	default:
+		tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "ID\tVISIBILITY\tUPDATED\tFILES\tDESCRIPTION")
+		for _, r := range rs {
+			visibility := "secret"
+			if r.Public {
+				visibility = "public"
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\n", r.ID, visibility,
+				r.UpdatedAt.Local().Format("2006-01-02 15:04"), len(r.Files), r.Description)
+		}
+		return errors.Wrap(tw.Flush(), "when writing table output")
+	}
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVarP(&output, "output", "o", outputText, "Output format: text, json, yaml, or template")
+	rootCmd.PersistentFlags().StringVar(&templateText, "template", "",
+		"Go text/template used with --output template, e.g. '{{.ID}} {{range .Files}}{{.RawURL}} {{end}}'")
+}
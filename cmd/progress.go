@@ -0,0 +1,204 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/shihanng/bgist/gist"
+)
+
+const (
+	progressAuto  = "auto"
+	progressBar   = "bar"
+	progressPlain = "plain"
+	progressNone  = "none"
+
+	barWidth = 30
+)
+
+var progressMode string
+
+// newProgress returns the renderer selected by --progress. In auto mode a
+// progress bar is drawn when w is a terminal, plain lines otherwise so that
+// CI logs stay readable.
+func newProgress(w *os.File) (gist.Progress, error) {
+	switch progressMode {
+	case progressAuto:
+		if isTerminal(w) {
+			return newBarProgress(w), nil
+		}
+		return newLineProgress(w), nil
+	case progressBar:
+		return newBarProgress(w), nil
+	case progressPlain:
+		return newLineProgress(w), nil
+	case progressNone:
+		return nil, nil
+	default:
+		return nil, errors.Errorf("unknown progress mode %q", progressMode)
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+func humanBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// barProgress redraws a single line per file.
+type barProgress struct {
+	w      io.Writer
+	drawn  int64
+	copied int64
+	total  int64
+}
+
+func newBarProgress(w io.Writer) *barProgress {
+	return &barProgress{w: w, drawn: -1}
+}
+
+func (p *barProgress) Copied(filename string, copied, total int64) {
+	p.copied, p.total = copied, total
+
+	// Redraw on every percent, or every 256 KiB when the total is unknown.
+	mark := copied >> 18
+	if total > 0 {
+		mark = copied * 100 / total
+	}
+	if mark == p.drawn {
+		return
+	}
+	p.drawn = mark
+
+	p.draw(filename)
+}
+
+func (p *barProgress) draw(filename string) {
+	if p.total <= 0 {
+		fmt.Fprintf(p.w, "\r%s %s", filename, humanBytes(p.copied))
+		return
+	}
+
+	// A file that grows while it is copied exceeds the total of its stat.
+	percent := p.copied * 100 / p.total
+	if percent > 100 {
+		percent = 100
+	}
+
+	filled := int(percent * barWidth / 100)
+	fmt.Fprintf(p.w, "\r%s [%s%s] %3d%% %s/%s", filename,
+		strings.Repeat("=", filled), strings.Repeat(" ", barWidth-filled),
+		percent, humanBytes(p.copied), humanBytes(p.total))
+}
+
+func (p *barProgress) Done(filename string) {
+	if p.copied < p.total {
+		p.copied = p.total
+	}
+	p.draw(filename)
+	fmt.Fprintln(p.w)
+
+	p.drawn, p.copied, p.total = -1, 0, 0
+}
+
+// Write passes the push progress through as the server already redraws its
+// lines with carriage returns.
+func (p *barProgress) Write(b []byte) (int, error) {
+	return p.w.Write(b)
+}
+
+// lineProgress prints a line on every 10 percent and only complete lines of
+// the push progress.
+type lineProgress struct {
+	w       io.Writer
+	printed int64
+	copied  int64
+	line    []byte
+}
+
+func newLineProgress(w io.Writer) *lineProgress {
+	return &lineProgress{w: w}
+}
+
+func (p *lineProgress) Copied(filename string, copied, total int64) {
+	p.copied = copied
+
+	if total <= 0 {
+		return
+	}
+
+	step := copied * 10 / total * 10
+	if step <= p.printed || step >= 100 {
+		return
+	}
+	p.printed = step
+
+	fmt.Fprintf(p.w, "%s: %d%% (%s/%s)\n", filename, step, humanBytes(copied), humanBytes(total))
+}
+
+func (p *lineProgress) Done(filename string) {
+	fmt.Fprintf(p.w, "%s: done (%s)\n", filename, humanBytes(p.copied))
+	p.printed, p.copied = 0, 0
+}
+
+// Write keeps only the last update of a line redrawn with carriage returns.
+func (p *lineProgress) Write(b []byte) (int, error) {
+	for _, c := range b {
+		switch c {
+		case '\r':
+			p.line = p.line[:0]
+		case '\n':
+			if len(bytes.TrimSpace(p.line)) > 0 {
+				if _, err := fmt.Fprintf(p.w, "%s\n", p.line); err != nil {
+					return 0, err
+				}
+			}
+			p.line = p.line[:0]
+		default:
+			p.line = append(p.line, c)
+		}
+	}
+	return len(b), nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&progressMode, "progress", progressAuto,
+		"Progress output on stderr: auto, bar, plain, or none")
+}
@@ -0,0 +1,156 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package cmd
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/shihanng/bgist/gist"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// progressStep is a call on a gist.Progress, Done when done is set.
+type progressStep struct {
+	copied, total int64
+	done          bool
+}
+
+func runProgress(p gist.Progress, steps []progressStep) {
+	for _, s := range steps {
+		if s.done {
+			p.Done("a.png")
+			continue
+		}
+		p.Copied("a.png", s.copied, s.total)
+	}
+}
+
+func TestBarProgress(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		steps    []progressStep
+		expected string
+	}{
+		{
+			name:  "known total",
+			steps: []progressStep{{copied: 512, total: 1024}, {copied: 1024, total: 1024}, {done: true}},
+			expected: "\ra.png [===============               ]  50% 512 B/1.0 KiB" +
+				"\ra.png [==============================] 100% 1.0 KiB/1.0 KiB" +
+				"\ra.png [==============================] 100% 1.0 KiB/1.0 KiB\n",
+		},
+		{
+			name:     "same percent is drawn once",
+			steps:    []progressStep{{copied: 100, total: 1000}, {copied: 101, total: 1000}},
+			expected: "\ra.png [===                           ]  10% 100 B/1000 B",
+		},
+		{
+			name:  "file grows while copied",
+			steps: []progressStep{{copied: 2048, total: 1024}, {done: true}},
+			expected: "\ra.png [==============================] 100% 2.0 KiB/1.0 KiB" +
+				"\ra.png [==============================] 100% 2.0 KiB/1.0 KiB\n",
+		},
+		{
+			name:     "unknown total",
+			steps:    []progressStep{{copied: 10, total: -1}, {done: true}},
+			expected: "\ra.png 10 B\ra.png 10 B\n",
+		},
+	} {
+		var b bytes.Buffer
+		runProgress(newBarProgress(&b), tc.steps)
+		assert.Equal(t, tc.expected, b.String(), tc.name)
+	}
+}
+
+func TestLineProgress(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		steps    []progressStep
+		expected string
+	}{
+		{
+			name: "every 10 percent",
+			steps: []progressStep{
+				{copied: 50, total: 1000}, {copied: 150, total: 1000}, {copied: 190, total: 1000},
+				{copied: 350, total: 1000}, {copied: 1000, total: 1000}, {done: true},
+			},
+			expected: "a.png: 10% (150 B/1000 B)\n" +
+				"a.png: 30% (350 B/1000 B)\n" +
+				"a.png: done (1000 B)\n",
+		},
+		{
+			name:     "file grows while copied",
+			steps:    []progressStep{{copied: 2048, total: 1024}, {done: true}},
+			expected: "a.png: done (2.0 KiB)\n",
+		},
+		{
+			name:     "unknown total",
+			steps:    []progressStep{{copied: 10, total: -1}, {done: true}},
+			expected: "a.png: done (10 B)\n",
+		},
+	} {
+		var b bytes.Buffer
+		runProgress(newLineProgress(&b), tc.steps)
+		assert.Equal(t, tc.expected, b.String(), tc.name)
+	}
+}
+
+func TestLineProgressWrite(t *testing.T) {
+	var b bytes.Buffer
+	p := newLineProgress(&b)
+
+	_, err := p.Write([]byte("Counting objects: 50%\rCounting objects: 100%\n\n  \nDone"))
+	assert.NoError(t, err)
+	_, err = p.Write([]byte("\n"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Counting objects: 100%\nDone\n", b.String())
+}
+
+func TestNewProgress(t *testing.T) {
+	defer func() { progressMode = progressAuto }()
+
+	f, err := ioutil.TempFile("", "bgist-test-")
+	require.NoError(t, err)
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	for _, tc := range []struct {
+		mode     string
+		expected gist.Progress
+	}{
+		{progressAuto, newLineProgress(f)},
+		{progressPlain, newLineProgress(f)},
+		{progressBar, newBarProgress(f)},
+		{progressNone, nil},
+	} {
+		progressMode = tc.mode
+		p, err := newProgress(f)
+		assert.NoError(t, err, tc.mode)
+		assert.Equal(t, tc.expected, p, tc.mode)
+	}
+
+	progressMode = "fancy"
+	_, err = newProgress(f)
+	assert.Error(t, err)
+}
@@ -22,85 +22,315 @@ package cmd
 
 import (
 	"context"
-	"errors"
 	"fmt"
 	"os"
+	"time"
 
-	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
 	"github.com/shihanng/bgist/gist"
 	"github.com/spf13/cobra"
-	"github.com/spf13/viper"
+	"github.com/spf13/pflag"
+)
+
+const (
+	storageAuto   = "auto"
+	storageMemory = "memory"
+	storageDisk   = "disk"
 )
 
 var (
-	public      bool
-	description string
-	accessToken string
+	public        bool
+	description   string
+	rename        string
+	keepOnFailure bool
+	stdinName     string
+	retries       int
+	retryBackoff  time.Duration
+	accessToken   string
+	progress      gist.Progress
 
-	dummyFilename = "dummy.go"
-	dummyContent  = "package dummy"
+	storageMode   string
+	diskThreshold int64
 )
 
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
-	Use:     "bgist",
-	Example: "BGIST_GITHUB_ACCESS_TOKEN=secret bgist -d \"a demo\" photo-1.png photo-2.jpg",
-	Short:   "A tool to upload image/binary file to gist.github.com.",
+	Use: "bgist",
+	Example: `BGIST_GITHUB_ACCESS_TOKEN=secret bgist -d "a demo" photo-1.png photo-2.jpg
+pg_dump mydb | BGIST_GITHUB_ACCESS_TOKEN=secret bgist --name dump.sql -`,
+	Short: "A tool to upload image/binary file to gist.github.com.",
 	Long: `A tool to upload image/binary file to gist.github.com.
 
 GitHub's personal access token should be provided as BGIST_GITHUB_ACCESS_TOKEN
-for this tool to work.
+or as github_access_token in the configuration file for this tool to work.
+
+Directories are uploaded recursively. Since gists cannot hold subdirectories,
+nested paths are flattened, e.g. assets/a/logo.png becomes assets__a__logo.png,
+and the mapping is recorded in .bgist-manifest.json of the gist.
 
 https://github.com/shihanng/bgist`,
-	Args: cobra.MinimumNArgs(1),
-	RunE: actual,
+	Args:              cobra.MinimumNArgs(1),
+	PersistentPreRunE: setup,
+	RunE:              actual,
 }
 
-func actual(cmd *cobra.Command, args []string) error {
+// setup applies the configuration file and validates the persistent flags
+// shared by every command.
+func setup(cmd *cobra.Command, args []string) error {
+	if err := loadConfig(); err != nil {
+		return err
+	}
+
+	if err := selectProfile(cmd); err != nil {
+		return err
+	}
+
+	if err := applyConfig(cmd); err != nil {
+		return err
+	}
+
+	if _, err := storageOptions(0); err != nil {
+		return err
+	}
+
+	if _, _, err := enterpriseURLs(); err != nil {
+		return err
+	}
+
+	if _, err := transportOptions(); err != nil {
+		return err
+	}
+
+	if _, err := messageTemplate(); err != nil {
+		return err
+	}
+
+	var err error
+	progress, err = newProgress(os.Stderr)
+	return err
+}
+
+func checkAccessToken() error {
+	if err := resolveAccessToken(); err != nil {
+		return err
+	}
+
 	if accessToken == "" {
-		fmt.Println(`GitHub's personal access token is needed as environment variable BGIST_GITHUB_ACCESS_TOKEN.`)
-		fmt.Println(`It can be obtained from https://github.com/settings/tokens. The require scope is "gist".`)
+		fmt.Println(`GitHub's personal access token is needed as environment variable BGIST_GITHUB_ACCESS_TOKEN,`)
+		fmt.Println(`from a git credential helper, a token file, token_command, or ~/.netrc.`)
+		fmt.Printf("It can be obtained from %s. The require scope is \"gist\".\n", tokenURL())
 		return errors.New("BGIST_GITHUB_ACCESS_TOKEN is empty")
 	}
+	return nil
+}
 
-	ctx := context.Background()
+// planUploads validates args before any API call is made. "-" stands for
+// stdin which is uploaded with the name given by --name.
+func planUploads(args []string) ([]gist.Upload, error) {
+	var (
+		paths   []string
+		readers []gist.Upload
+	)
 
-	client := gist.NewClient(ctx, accessToken)
+	for _, a := range args {
+		if a != "-" {
+			paths = append(paths, a)
+			continue
+		}
 
-	info, err := client.CreateGist(ctx,
-		gist.Description(description),
-		gist.Public(public),
-		gist.File(&github.GistFile{Filename: &dummyFilename, Content: &dummyContent}),
-	)
+		if len(readers) > 0 {
+			return nil, errors.New("stdin (-) can only be given once")
+		}
+		if stdinName == "" {
+			return nil, errors.New("--name is required when reading from stdin (-)")
+		}
+		readers = append(readers, gist.ReaderUpload(stdinName, os.Stdin))
+	}
+
+	return gist.Plan(paths, gist.RenameStrategy(rename), readers...)
+}
+
+func retryPolicy() gist.RetryPolicy {
+	p := gist.DefaultRetryPolicy
+	p.Attempts = retries + 1
+	p.Backoff = retryBackoff
+	return p
+}
+
+func actual(cmd *cobra.Command, args []string) error {
+	if err := checkAccessToken(); err != nil {
+		return err
+	}
+
+	tmpl, err := checkOutput()
 	if err != nil {
 		return err
 	}
-	fmt.Println("Created", info.HTMLURL)
 
-	g, err := gist.NewGit(info, accessToken)
+	uploads, err := planUploads(args)
 	if err != nil {
 		return err
 	}
 
-	for _, f := range args {
-		if err := g.Add(f); err != nil {
-			return err
-		}
+	ctx := context.Background()
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
 	}
 
-	if err := g.Remove(dummyFilename); err != nil {
+	user, err := preflight(ctx, client)
+	if err != nil {
 		return err
 	}
 
-	if err := g.Commit("update"); err != nil {
+	u, err := newUploader(client, user)
+	if err != nil {
 		return err
 	}
 
-	if err := g.Push(); err != nil {
+	res, err := u.Create(ctx, uploads, gist.Description(description), gist.Public(public))
+	if e, ok := err.(*gist.IncompleteError); ok {
+		reportIncomplete(e)
+	}
+	if err != nil {
 		return err
 	}
 
-	return nil
+	r, err := newResult(res.Info, uploads)
+	if err != nil {
+		return err
+	}
+
+	return printResult(os.Stdout, tmpl, "Created", r)
+}
+
+// newUploader configures an Uploader with the flags. The author is resolved
+// for user unless given by --author.
+func newUploader(client *gist.Client, user gist.Identity) (*gist.Uploader, error) {
+	ops, err := transportOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	identity, resolve, err := flagIdentity()
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := messageTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	u := gist.NewUploader(client, accessToken)
+	u.GitOptions = append(ops, identity...)
+	u.Storage = storageOptions
+	u.Retry = retryPolicy()
+	u.Progress = progress
+	u.Message = func(uploads []gist.Upload) (string, error) {
+		return commitMessage(t, uploads, time.Now())
+	}
+	u.CommitPerFile = commitPerFile
+	if resolve {
+		u.Identity = &user
+	}
+	u.KeepOnFailure = keepOnFailure
+
+	return u, nil
+}
+
+// clone fetches the gist identified by arg, an ID or a URL, and clones it.
+// The caller must close the returned Git.
+func clone(ctx context.Context, client *gist.Client, arg string, ops ...gist.GitOption) (gist.Info, *gist.Git, error) {
+	id, err := gist.ParseID(arg)
+	if err != nil {
+		return gist.Info{}, nil, err
+	}
+
+	info, err := client.GetGist(ctx, id)
+	if err != nil {
+		return gist.Info{}, nil, err
+	}
+
+	var size int64
+	for _, f := range info.Files {
+		size += int64(f.Size)
+	}
+
+	g, err := newGit(info, size, ops...)
+	if err != nil {
+		return gist.Info{}, nil, err
+	}
+
+	return info, g, nil
+}
+
+// gitOptions selects the storage for size and the transport given by the
+// flags.
+func gitOptions(size int64) ([]gist.GitOption, error) {
+	storage, err := storageOptions(size)
+	if err != nil {
+		return nil, err
+	}
+
+	transport, err := transportOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return append(storage, transport...), nil
+}
+
+// newGit clones the gist with gitOptions for size and ops.
+func newGit(info gist.Info, size int64, ops ...gist.GitOption) (*gist.Git, error) {
+	flags, err := gitOptions(size)
+	if err != nil {
+		return nil, err
+	}
+	ops = append(flags, ops...)
+
+	g, err := gist.NewGit(info, accessToken, ops...)
+	if err != nil {
+		return nil, err
+	}
+	g.Retry = retryPolicy()
+	g.Progress = progress
+
+	return g, nil
+}
+
+// storageOptions selects where the clone is kept according to --storage. In
+// auto mode the disk is used when size exceeds --disk-threshold or is unknown,
+// i.e. negative.
+func storageOptions(size int64) ([]gist.GitOption, error) {
+	switch storageMode {
+	case storageMemory:
+		return nil, nil
+	case storageDisk:
+		return []gist.GitOption{gist.OnDisk("")}, nil
+	case storageAuto:
+	default:
+		return nil, errors.Errorf("unknown storage %q", storageMode)
+	}
+
+	if size < 0 || size > diskThreshold<<20 {
+		return []gist.GitOption{gist.OnDisk("")}, nil
+	}
+	return nil, nil
+}
+
+// reportIncomplete tells what happened to the gist whose upload failed,
+// depending on --keep-on-failure.
+func reportIncomplete(e *gist.IncompleteError) {
+	switch {
+	case e.Deleted:
+		fmt.Fprintf(os.Stderr, "Deleted the incomplete gist %s\n", e.Info.ID)
+	case e.DeleteErr != nil:
+		fmt.Fprintf(os.Stderr, "Could not delete the incomplete gist %s (%s): %v\n", e.Info.ID, e.Info.HTMLURL, e.DeleteErr)
+	default:
+		fmt.Fprintf(os.Stderr, "Kept the incomplete gist %s (%s)\n", e.Info.ID, e.Info.HTMLURL)
+	}
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -113,14 +343,36 @@ func Execute() {
 }
 
 func init() {
-	rootCmd.PersistentFlags().BoolVar(&public, "public", false, "Publish as public gist")
-	rootCmd.PersistentFlags().StringVarP(&description, "description", "d", "", "Description of the gist")
+	rootCmd.Flags().BoolVar(&public, "public", false, "Publish as public gist")
+	rootCmd.Flags().StringVarP(&description, "description", "d", "", "Description of the gist")
+	rootCmd.Flags().BoolVar(&keepOnFailure, "keep-on-failure", false,
+		"Keep the newly created gist when the upload fails instead of deleting it")
 
-	viper.SetEnvPrefix("bgist")
-	if err := viper.BindEnv("github_access_token"); err != nil {
-		fmt.Println(err)
-		os.Exit(1)
-	}
+	// Flags shared by the commands that upload files.
+	uploadFlags := pflag.NewFlagSet("upload", pflag.ExitOnError)
+	uploadFlags.StringVar(&rename, "rename", string(gist.RenameError),
+		"What to do when two files share a gist filename: error, suffix, or hash")
+	uploadFlags.StringVar(&stdinName, "name", "", "Gist filename for the content read from stdin (-)")
+	uploadFlags.StringVar(&embed, "embed", "",
+		"Generate a snippet for every uploaded file: markdown, html, bbcode, rst, or asciidoc")
+	rootCmd.Flags().AddFlagSet(uploadFlags)
+	updateCmd.Flags().AddFlagSet(uploadFlags)
+
+	// Flags shared by the commands that clone a gist.
+	storageFlags := pflag.NewFlagSet("storage", pflag.ExitOnError)
+	storageFlags.StringVar(&storageMode, "storage", storageAuto,
+		"Where the gist is cloned to: auto, memory, or disk (a temporary directory)")
+	storageFlags.Int64Var(&diskThreshold, "disk-threshold", 100,
+		"Total size in MiB above which --storage auto uses the disk")
+	rootCmd.Flags().AddFlagSet(storageFlags)
+	updateCmd.Flags().AddFlagSet(storageFlags)
+	downloadCmd.Flags().AddFlagSet(storageFlags)
+	historyCmd.Flags().AddFlagSet(storageFlags)
+	showCmd.Flags().AddFlagSet(storageFlags)
+	restoreCmd.Flags().AddFlagSet(storageFlags)
 
-	accessToken = viper.GetString("github_access_token")
+	rootCmd.PersistentFlags().IntVar(&retries, "retries", gist.DefaultRetryPolicy.Attempts-1,
+		"How many times a failed GitHub API call or push is retried")
+	rootCmd.PersistentFlags().DurationVar(&retryBackoff, "retry-backoff", gist.DefaultRetryPolicy.Backoff,
+		"Delay before the first retry, doubled after every attempt")
 }
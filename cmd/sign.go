@@ -0,0 +1,97 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"github.com/shihanng/bgist/gist"
+)
+
+var signKey string
+
+// signOptions reads --sign-key. The passphrase of an encrypted key is read
+// from BGIST_SIGN_PASSPHRASE or sign_passphrase in the configuration file,
+// else asked for on the terminal.
+func signOptions() ([]gist.GitOption, error) {
+	if signKey == "" {
+		return nil, nil
+	}
+
+	path, err := homedir.Expand(signKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "when expanding %s", signKey)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "when opening the signing key")
+	}
+	defer f.Close()
+
+	key, err := gist.ReadSignKey(f, func() (string, error) {
+		if p, ok := lookup("sign_passphrase"); ok {
+			return p, nil
+		}
+		return promptPassphrase(fmt.Sprintf("Passphrase for %s: ", signKey))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return []gist.GitOption{gist.Sign(key)}, nil
+}
+
+// promptPassphrase asks for a passphrase on stderr and reads it from the
+// terminal without echoing it.
+func promptPassphrase(prompt string) (string, error) {
+	errNoPrompt := errors.New("the signing key is encrypted, set BGIST_SIGN_PASSPHRASE")
+	if !isTerminal(os.Stdin) {
+		return "", errNoPrompt
+	}
+
+	// Never read the passphrase when it would be echoed.
+	if err := stty("-echo"); err != nil {
+		return "", errors.Wrap(errNoPrompt, "when turning off the terminal echo")
+	}
+	defer stty("echo")
+
+	fmt.Fprint(os.Stderr, prompt)
+	defer fmt.Fprintln(os.Stderr)
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return "", errors.Wrap(err, "when reading the passphrase")
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+func stty(arg string) error {
+	cmd := exec.Command("stty", arg)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
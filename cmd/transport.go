@@ -0,0 +1,71 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/shihanng/bgist/gist"
+)
+
+const (
+	transportHTTPS = "https"
+	transportSSH   = "ssh"
+)
+
+var (
+	gitTransport string
+	sshKey       string
+	sshHost      string
+	knownHosts   []string
+)
+
+// transportOptions selects how the gist is cloned and pushed according to
+// --transport. The passphrase of --ssh-key is read from BGIST_SSH_PASSPHRASE
+// or ssh_passphrase in the configuration file.
+func transportOptions() ([]gist.GitOption, error) {
+	switch gitTransport {
+	case transportHTTPS:
+		return nil, nil
+	case transportSSH:
+	default:
+		return nil, errors.Errorf("unknown transport %q", gitTransport)
+	}
+
+	passphrase, _ := lookup("ssh_passphrase")
+
+	return []gist.GitOption{gist.SSH(gist.SSHOptions{
+		Host:       sshHost,
+		KeyFile:    sshKey,
+		Passphrase: passphrase,
+		KnownHosts: knownHosts,
+	})}, nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringVar(&gitTransport, "transport", transportHTTPS,
+		"How the gist is cloned and pushed: https (with the access token) or ssh")
+	rootCmd.PersistentFlags().StringVar(&sshKey, "ssh-key", "",
+		"Private key for --transport ssh (default ssh-agent, then IdentityFile of ~/.ssh/config)")
+	rootCmd.PersistentFlags().StringVar(&sshHost, "ssh-host", "",
+		"Host, or a Host alias of ~/.ssh/config, used instead of the gist's git host")
+	rootCmd.PersistentFlags().StringSliceVar(&knownHosts, "known-hosts", nil,
+		"known_hosts files to verify the host key with (default ~/.ssh/known_hosts)")
+}
@@ -0,0 +1,95 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"os"
+
+	"github.com/shihanng/bgist/gist"
+	"github.com/spf13/cobra"
+)
+
+// updateCmd represents the update command
+var updateCmd = &cobra.Command{
+	Use:     "update <gist-id|gist-url> <file>...",
+	Example: "BGIST_GITHUB_ACCESS_TOKEN=secret bgist update abc123 photo-3.png",
+	Short:   "Add or replace files in an existing gist.",
+	Long: `Add or replace files in an existing gist.
+
+Files with the same name as the ones already in the gist are overwritten.`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: update,
+}
+
+func update(cmd *cobra.Command, args []string) error {
+	if err := checkAccessToken(); err != nil {
+		return err
+	}
+
+	tmpl, err := checkOutput()
+	if err != nil {
+		return err
+	}
+
+	uploads, err := planUploads(args[1:])
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	user, err := preflight(ctx, client)
+	if err != nil {
+		return err
+	}
+
+	id, err := gist.ParseID(args[0])
+	if err != nil {
+		return err
+	}
+
+	u, err := newUploader(client, user)
+	if err != nil {
+		return err
+	}
+
+	res, err := u.Update(ctx, id, uploads)
+	if err != nil {
+		return err
+	}
+
+	r, err := newResult(res.Info, uploads)
+	if err != nil {
+		return err
+	}
+
+	return printResult(os.Stdout, tmpl, "Updated", r)
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+}
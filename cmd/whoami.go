@@ -0,0 +1,173 @@
+// Copyright © 2018 Shi Han NG
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/shihanng/bgist/gist"
+	"github.com/spf13/cobra"
+)
+
+// whoamiCmd represents the whoami command
+var whoamiCmd = &cobra.Command{
+	Use:     "whoami",
+	Example: "BGIST_GITHUB_ACCESS_TOKEN=secret bgist whoami",
+	Short:   "Show the user, scopes, and rate limit of the access token.",
+	Long: `Show the user, scopes, and rate limit of the access token.
+
+The same check runs before a gist is created, updated, deleted, or restored,
+so that a token without the gist scope fails early.`,
+	Args: cobra.NoArgs,
+	RunE: whoami,
+}
+
+type identityResult struct {
+	Host          string    `json:"host" yaml:"host"`
+	Login         string    `json:"login" yaml:"login"`
+	Name          string    `json:"name" yaml:"name"`
+	Email         string    `json:"email" yaml:"email"`
+	Scopes        []string  `json:"scopes" yaml:"scopes"`
+	RateLimit     int       `json:"rate_limit" yaml:"rate_limit"`
+	RateRemaining int       `json:"rate_remaining" yaml:"rate_remaining"`
+	RateReset     time.Time `json:"rate_reset" yaml:"rate_reset"`
+}
+
+func whoami(cmd *cobra.Command, args []string) error {
+	if err := checkAccessToken(); err != nil {
+		return err
+	}
+
+	tmpl, err := checkOutput()
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	client, err := newClient(ctx)
+	if err != nil {
+		return err
+	}
+
+	i, err := client.WhoAmI(ctx)
+	if err != nil {
+		return remediate(err)
+	}
+
+	r := identityResult{
+		Host:          hostname(),
+		Login:         i.Login,
+		Name:          i.Name,
+		Email:         i.Email,
+		Scopes:        i.Scopes,
+		RateLimit:     i.RateLimit,
+		RateRemaining: i.RateRemaining,
+		RateReset:     i.RateReset,
+	}
+	if err := printIdentity(os.Stdout, tmpl, r); err != nil {
+		return err
+	}
+
+	return remediate(checkIdentity(i))
+}
+
+// preflight verifies the access token before a gist is changed.
+func preflight(ctx context.Context, client *gist.Client) (gist.Identity, error) {
+	i, err := client.WhoAmI(ctx)
+	if err != nil {
+		return gist.Identity{}, remediate(err)
+	}
+	return i, remediate(checkIdentity(i))
+}
+
+func checkIdentity(i gist.Identity) error {
+	if err := i.RequireScope(gist.ScopeGist); err != nil {
+		return err
+	}
+
+	if i.RateLimit > 0 && i.RateRemaining == 0 {
+		return errors.Errorf("the rate limit of %d calls is used up until %s",
+			i.RateLimit, i.RateReset.Local().Format("15:04:05"))
+	}
+	return nil
+}
+
+// remediate explains on stderr how to fix a rejected token or a missing
+// scope and returns err.
+func remediate(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	switch e := errors.Cause(err).(type) {
+	case *gist.ScopeError:
+		fmt.Fprintf(os.Stderr, "Add the %q scope to the token at %s, or create a new one.\n",
+			e.Scope, tokenURL())
+	case *github.ErrorResponse:
+		if e.Response.StatusCode == http.StatusUnauthorized {
+			fmt.Fprintf(os.Stderr, "%s rejected the access token, it may be expired or revoked.\n", hostname())
+			fmt.Fprintf(os.Stderr, "Create a new one with the %q scope at %s.\n", gist.ScopeGist, tokenURL())
+		}
+	}
+	return err
+}
+
+// printIdentity writes r in the format selected by --output.
+func printIdentity(w io.Writer, t *template.Template, r identityResult) error {
+	if ok, err := encode(w, r); ok {
+		return err
+	}
+
+	if output == outputTemplate {
+		return errors.Wrap(t.Execute(w, r), "when writing template output")
+	}
+
+	user := r.Login
+	if r.Name != "" {
+		user += " (" + r.Name + ")"
+	}
+	if r.Email != "" {
+		user += " <" + r.Email + ">"
+	}
+
+	scopes := "unknown"
+	if r.Scopes != nil {
+		scopes = strings.Join(r.Scopes, ", ")
+	}
+
+	_, err := fmt.Fprintf(w, "Logged in to %s as %s\nToken scopes: %s\nRate limit: %d of %d remaining, resets at %s\n",
+		r.Host, user, scopes, r.RateRemaining, r.RateLimit, r.RateReset.Local().Format("15:04:05"))
+	return err
+}
+
+func init() {
+	rootCmd.AddCommand(whoamiCmd)
+}
@@ -0,0 +1,86 @@
+package gist
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// Person is the author or the committer of a commit.
+type Person struct {
+	Name  string
+	Email string
+}
+
+func (p Person) String() string {
+	return fmt.Sprintf("%s <%s>", p.Name, p.Email)
+}
+
+// ParsePerson parses v in the form of git's --author, "Name <email>".
+func ParsePerson(v string) (Person, error) {
+	a, err := mail.ParseAddress(v)
+	if err != nil {
+		return Person{}, errors.Wrapf(err, "when parsing %q, expected \"Name <email>\"", v)
+	}
+	if a.Name == "" {
+		return Person{}, errors.Errorf("%q has no name, expected \"Name <email>\"", v)
+	}
+	return Person{Name: a.Name, Email: a.Address}, nil
+}
+
+// Author resolves the identity of the access token's user i, as returned by
+// WhoAmI, for commits. The email is the primary verified one, which needs the
+// user:email scope, else the noreply address of GitHub,
+// <id>+<login>@users.noreply.<Host>.
+func (c *Client) Author(ctx context.Context, i Identity) (Person, error) {
+	p := Person{Name: i.Name, Email: i.Email}
+	if p.Name == "" {
+		p.Name = i.Login
+	}
+
+	email, err := c.primaryEmail(ctx)
+	if err != nil {
+		return Person{}, err
+	}
+	if email != "" {
+		p.Email = email
+	}
+
+	if p.Email == "" {
+		p.Email = fmt.Sprintf("%d+%s@users.noreply.%s", i.UserID, i.Login, c.Host)
+	}
+	return p, nil
+}
+
+// primaryEmail returns the primary verified email, or an empty string when
+// there is none or the token may not read the emails.
+func (c *Client) primaryEmail(ctx context.Context) (string, error) {
+	var emails []*github.UserEmail
+
+	err := c.Retry.run(ctx, func(int) error {
+		var err error
+		emails, _, err = c.users.ListEmails(ctx, &github.ListOptions{PerPage: 100})
+		return err
+	}, classifyAPI(true))
+	if e, ok := err.(*github.ErrorResponse); ok {
+		switch e.Response.StatusCode {
+		case http.StatusForbidden, http.StatusNotFound, http.StatusUnauthorized:
+			return "", nil
+		}
+	}
+	if err != nil {
+		return "", errors.Wrap(err, "when listing emails")
+	}
+
+	for _, e := range emails {
+		if e.GetPrimary() && e.GetVerified() {
+			return strings.TrimSpace(e.GetEmail()), nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,82 @@
+package gist
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	github "github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func TestParsePerson(t *testing.T) {
+	p, err := ParsePerson("John Doe <jdoe@example.com>")
+	assert.NoError(t, err)
+	assert.Equal(t, Person{Name: "John Doe", Email: "jdoe@example.com"}, p)
+	assert.Equal(t, "John Doe <jdoe@example.com>", p.String())
+
+	_, err = ParsePerson("jdoe@example.com")
+	assert.Error(t, err)
+
+	_, err = ParsePerson("John Doe")
+	assert.Error(t, err)
+}
+
+func TestClientAuthor(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUserer := NewMockuserer(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.users = mockUserer
+
+	// The user is not fetched again, no Get is expected.
+	i := Identity{UserID: 42, Login: "johndoe", Email: "public@example.com"}
+
+	mockUserer.EXPECT().ListEmails(ctx, gomock.Any()).Return([]*github.UserEmail{
+		{Email: github.String("old@example.com"), Primary: github.Bool(false), Verified: github.Bool(true)},
+		{Email: github.String("jdoe@example.com"), Primary: github.Bool(true), Verified: github.Bool(true)},
+	}, nil, nil)
+
+	p, err := c.Author(ctx, i)
+	assert.NoError(t, err)
+	assert.Equal(t, Person{Name: "johndoe", Email: "jdoe@example.com"}, p)
+
+	i.Email = ""
+	mockUserer.EXPECT().ListEmails(ctx, gomock.Any()).Return(nil, nil, newErrorResponse(http.StatusNotFound)).Times(2)
+
+	p, err = c.Author(ctx, i)
+	assert.NoError(t, err)
+	assert.Equal(t, Person{Name: "johndoe", Email: "42+johndoe@users.noreply.github.com"}, p)
+
+	// The noreply address is on the web host, not the API host.
+	c.Host = "github.example.com"
+	p, err = c.Author(ctx, i)
+	assert.NoError(t, err)
+	assert.Equal(t, "42+johndoe@users.noreply.github.example.com", p.Email)
+}
+
+func TestGitAuthor(t *testing.T) {
+	stub, restore := stubClone()
+	defer restore()
+
+	author := Person{Name: "Jane Doe", Email: "jane@example.com"}
+	committer := Person{Name: "CI", Email: "ci@example.com"}
+
+	g, err := NewGit(testInfo, "secret", Author(author), Committer(committer))
+	require.NoError(t, err)
+
+	assert.NoError(t, g.Add("./testdata/test_1.txt"))
+	hash, err := g.Commit("adding test_1.txt")
+	require.NoError(t, err)
+
+	c, err := stub.repo.CommitObject(plumbing.NewHash(hash))
+	require.NoError(t, err)
+	assert.Equal(t, author, Person{Name: c.Author.Name, Email: c.Author.Email})
+	assert.Equal(t, committer, Person{Name: c.Committer.Name, Email: c.Committer.Email})
+}
@@ -0,0 +1,82 @@
+package gist
+
+import (
+	"fmt"
+	"html"
+	"mime"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// EmbedFormat is the markup of a snippet generated by Embed.
+type EmbedFormat string
+
+const (
+	EmbedMarkdown EmbedFormat = "markdown"
+	EmbedHTML     EmbedFormat = "html"
+	EmbedBBCode   EmbedFormat = "bbcode"
+	EmbedRST      EmbedFormat = "rst"
+	EmbedAsciiDoc EmbedFormat = "asciidoc"
+)
+
+// EmbedFormats lists the valid values of EmbedFormat.
+var EmbedFormats = []EmbedFormat{EmbedMarkdown, EmbedHTML, EmbedBBCode, EmbedRST, EmbedAsciiDoc}
+
+// Embed returns a ready-to-paste snippet that shows the file at url. Images
+// are embedded, any other file is linked.
+func Embed(format EmbedFormat, filename, url string) (string, error) {
+	image := strings.HasPrefix(mime.TypeByExtension(path.Ext(filename)), "image/")
+
+	switch format {
+	case EmbedMarkdown:
+		alt := strings.NewReplacer("[", `\[`, "]", `\]`).Replace(filename)
+		if image {
+			return fmt.Sprintf("![%s](%s)", alt, url), nil
+		}
+		return fmt.Sprintf("[%s](%s)", alt, url), nil
+	case EmbedHTML:
+		if image {
+			return fmt.Sprintf(`<img src="%s" alt="%s">`, html.EscapeString(url), html.EscapeString(filename)), nil
+		}
+		return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(url), html.EscapeString(filename)), nil
+	case EmbedBBCode:
+		if image {
+			return fmt.Sprintf("[img]%s[/img]", url), nil
+		}
+		return fmt.Sprintf("[url=%s]%s[/url]", url, filename), nil
+	case EmbedRST:
+		if image {
+			return fmt.Sprintf(".. image:: %s\n   :alt: %s", url, filename), nil
+		}
+		return fmt.Sprintf("`%s <%s>`_", filename, url), nil
+	case EmbedAsciiDoc:
+		if image {
+			return fmt.Sprintf("image::%s[%s]", url, filename), nil
+		}
+		return fmt.Sprintf("link:%s[%s]", url, filename), nil
+	default:
+		return "", errors.Errorf("unknown embed format %q", format)
+	}
+}
+
+// PinnedRawURL rewrites the revision in a gist raw URL, i.e.
+// .../raw/<revision>/<filename>, to the given commit so that the link keeps
+// pointing at the same content when the gist is edited later.
+func PinnedRawURL(rawURL, commit string) string {
+	const marker = "/raw/"
+
+	i := strings.LastIndex(rawURL, marker)
+	if i < 0 || commit == "" {
+		return rawURL
+	}
+
+	rest := rawURL[i+len(marker):]
+	j := strings.Index(rest, "/")
+	if j < 0 {
+		return rawURL[:i+len(marker)] + commit + "/" + rest
+	}
+
+	return rawURL[:i+len(marker)] + commit + rest[j:]
+}
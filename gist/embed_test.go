@@ -0,0 +1,43 @@
+package gist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbed(t *testing.T) {
+	const url = "https://gist.githubusercontent.com/johndoe/abc123/raw/def456/logo.png"
+
+	for _, tc := range []struct {
+		format   EmbedFormat
+		filename string
+		expected string
+	}{
+		{EmbedMarkdown, "logo.png", "![logo.png](" + url + ")"},
+		{EmbedMarkdown, "notes.txt", "[notes.txt](" + url + ")"},
+		{EmbedHTML, "logo.png", `<img src="` + url + `" alt="logo.png">`},
+		{EmbedHTML, "a&b.txt", `<a href="` + url + `">a&amp;b.txt</a>`},
+		{EmbedBBCode, "logo.png", "[img]" + url + "[/img]"},
+		{EmbedRST, "logo.png", ".. image:: " + url + "\n   :alt: logo.png"},
+		{EmbedAsciiDoc, "logo.png", "image::" + url + "[logo.png]"},
+		{EmbedAsciiDoc, "notes.txt", "link:" + url + "[notes.txt]"},
+	} {
+		actual, err := Embed(tc.format, tc.filename, url)
+		require.NoError(t, err)
+		assert.Equal(t, tc.expected, actual, string(tc.format))
+	}
+
+	_, err := Embed(EmbedFormat("unknown"), "logo.png", url)
+	assert.Error(t, err)
+}
+
+func TestPinnedRawURL(t *testing.T) {
+	assert.Equal(t,
+		"https://gist.githubusercontent.com/johndoe/abc123/raw/0123abcd/logo.png",
+		PinnedRawURL("https://gist.githubusercontent.com/johndoe/abc123/raw/def456/logo.png", "0123abcd"))
+	assert.Equal(t,
+		"https://gist.githubusercontent.com/johndoe/abc123/raw/def456/logo.png",
+		PinnedRawURL("https://gist.githubusercontent.com/johndoe/abc123/raw/def456/logo.png", ""))
+}
@@ -0,0 +1,20 @@
+package gist
+
+import (
+	"path"
+	"strings"
+)
+
+// PathSeparator replaces the directory separator when a nested path is
+// flattened into a gist filename because gists cannot hold subdirectories.
+const PathSeparator = "__"
+
+// ManifestFilename is the file in the gist that records the original nested
+// path of every flattened file.
+const ManifestFilename = ".bgist-manifest.json"
+
+// FlattenPath maps a slash separated relative path, e.g. a/logo.png, to a
+// flat gist filename, e.g. a__logo.png.
+func FlattenPath(p string) string {
+	return strings.Join(strings.Split(path.Clean(p), "/"), PathSeparator)
+}
@@ -0,0 +1,21 @@
+package gist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFlattenPath(t *testing.T) {
+	for _, tc := range []struct {
+		nested string
+		flat   string
+	}{
+		{"logo.png", "logo.png"},
+		{"a/logo.png", "a__logo.png"},
+		{"assets/a/b/logo.png", "assets__a__b__logo.png"},
+		{"./a//logo.png", "a__logo.png"},
+	} {
+		assert.Equal(t, tc.flat, FlattenPath(tc.nested), tc.nested)
+	}
+}
@@ -3,6 +3,10 @@ package gist
 
 import (
 	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/google/go-github/github"
 	"github.com/pkg/errors"
@@ -16,33 +20,119 @@ var (
 
 type gister interface {
 	Create(context.Context, *github.Gist) (*github.Gist, *github.Response, error)
+	Get(context.Context, string) (*github.Gist, *github.Response, error)
+	Delete(context.Context, string) (*github.Response, error)
+	List(context.Context, string, *github.GistListOptions) ([]*github.Gist, *github.Response, error)
 }
 
 // Client should be created with NewClient.
 type Client struct {
-	gist gister
+	gist  gister
+	users userer
+
+	// Host is the web host of GitHub, e.g. github.com or the host of
+	// GitHub Enterprise Server, for the noreply email addresses. It is
+	// guessed from the API URL and should be set when the API is served
+	// from elsewhere.
+	Host string
+
+	// Retry is the policy for failed API calls, DefaultRetryPolicy unless
+	// changed.
+	Retry RetryPolicy
 }
 
 // NewClient created the client to create gist on GitHub.
 func NewClient(ctx context.Context, accessToken string) *Client {
-	auth := oauth2.NewClient(ctx, oauth2.StaticTokenSource(
+	return newClient(github.NewClient(oauthClient(ctx, accessToken)))
+}
+
+// NewEnterpriseClient creates the client for a GitHub Enterprise Server, e.g.
+// with baseURL https://github.example.com/api/v3/ and uploadURL
+// https://github.example.com/api/uploads/.
+func NewEnterpriseClient(ctx context.Context, accessToken, baseURL,
+	uploadURL string) (*Client, error) {
+
+	client, err := github.NewEnterpriseClient(baseURL, uploadURL,
+		oauthClient(ctx, accessToken))
+	if err != nil {
+		return nil, errors.Wrap(err, "when creating enterprise client")
+	}
+	return newClient(client), nil
+}
+
+func oauthClient(ctx context.Context, accessToken string) *http.Client {
+	return oauth2.NewClient(ctx, oauth2.StaticTokenSource(
 		&oauth2.Token{AccessToken: accessToken},
 	))
+}
 
-	client := github.NewClient(auth)
+func newClient(client *github.Client) *Client {
+	host := client.BaseURL.Hostname()
+	if host == "api.github.com" {
+		host = "github.com"
+	}
 
 	return &Client{
-		gist: client.Gists,
+		gist:  client.Gists,
+		users: client.Users,
+		Host:  host,
+		Retry: DefaultRetryPolicy,
 	}
 }
 
-// Info of the newly created gist.
+// Info of the gist.
 type Info struct {
-	ID      string
-	Name    string
-	Email   string
+	// ID of the gist, e.g. abc123 of https://gist.github.com/johndoe/abc123.
+	ID    string
+	Owner Owner
+
 	HTMLURL string
 	GitURL  string
+
+	Description string
+	Public      bool
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+
+	// Commit is the SHA of the revision the raw URLs of the Files are
+	// pinned to with Pin, e.g. the one pushed by Git. It is empty for an
+	// Info from the API, whose raw URLs point at the content of the file.
+	Commit string
+	Files  []FileInfo
+}
+
+// Owner of the gist.
+type Owner struct {
+	Login string
+	Name  string
+	Email string
+}
+
+// FileInfo describes a file in the gist.
+type FileInfo struct {
+	Name string
+	Size int
+	// RawURL points at this version of the file, so that it keeps working
+	// when the gist is edited later.
+	RawURL string
+}
+
+// Pin returns a copy of info with Commit and the raw URLs of the files set to
+// commit, e.g. the one just pushed.
+func (i Info) Pin(commit string) Info {
+	if commit == "" {
+		return i
+	}
+
+	i.Commit = commit
+
+	files := i.Files
+	i.Files = nil
+	for _, f := range files {
+		f.RawURL = PinnedRawURL(f.RawURL, commit)
+		i.Files = append(i.Files, f)
+	}
+	return i
 }
 
 // CreateGist creates the gist on GitHub based on the provided option.
@@ -53,18 +143,148 @@ func (c *Client) CreateGist(ctx context.Context, ops ...Option) (Info, error) {
 		o(&g)
 	}
 
-	created, _, err := c.gist.Create(ctx, &g)
+	var created *github.Gist
+
+	err := c.Retry.run(ctx, func(int) error {
+		var err error
+		created, _, err = c.gist.Create(ctx, &g)
+		return err
+	}, classifyAPI(false))
 	if err != nil {
 		return Info{}, errors.Wrap(err, "when creating new gist")
 	}
 
-	return Info{
-		ID:      created.GetOwner().GetLogin(),
-		Name:    created.GetOwner().GetName(),
-		Email:   created.GetOwner().GetEmail(),
-		HTMLURL: created.GetHTMLURL(),
-		GitURL:  created.GetGitPullURL(),
-	}, nil
+	return newInfo(created), nil
+}
+
+// GetGist retrieves the metadata of an existing gist on GitHub.
+func (c *Client) GetGist(ctx context.Context, id string) (Info, error) {
+	var got *github.Gist
+
+	err := c.Retry.run(ctx, func(int) error {
+		var err error
+		got, _, err = c.gist.Get(ctx, id)
+		return err
+	}, classifyAPI(true))
+	if err != nil {
+		return Info{}, errors.Wrap(err, "when getting gist")
+	}
+
+	return newInfo(got), nil
+}
+
+// ListFilter selects the gists returned by ListGists. The zero value selects
+// every gist.
+type ListFilter struct {
+	// Since only lists the gists updated at or after this time.
+	Since time.Time
+	// Public, when set, only lists public (true) or secret (false) gists.
+	Public *bool
+	// Filename only lists gists with a filename containing it.
+	Filename string
+	// Description only lists gists with a description containing it.
+	Description string
+}
+
+func (f ListFilter) match(info Info) bool {
+	if f.Public != nil && *f.Public != info.Public {
+		return false
+	}
+
+	if f.Description != "" && !strings.Contains(info.Description, f.Description) {
+		return false
+	}
+
+	if f.Filename == "" {
+		return true
+	}
+	for _, file := range info.Files {
+		if strings.Contains(file.Name, f.Filename) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListGists lists the gists of the authenticated user that match the filter,
+// following every page.
+func (c *Client) ListGists(ctx context.Context, filter ListFilter) ([]Info, error) {
+	opt := &github.GistListOptions{
+		Since:       filter.Since,
+		ListOptions: github.ListOptions{PerPage: 100},
+	}
+
+	var infos []Info
+	for {
+		var (
+			gists []*github.Gist
+			resp  *github.Response
+		)
+
+		err := c.Retry.run(ctx, func(int) error {
+			var err error
+			gists, resp, err = c.gist.List(ctx, "", opt)
+			return err
+		}, classifyAPI(true))
+		if err != nil {
+			return nil, errors.Wrap(err, "when listing gists")
+		}
+
+		for _, g := range gists {
+			if info := newInfo(g); filter.match(info) {
+				infos = append(infos, info)
+			}
+		}
+
+		if resp == nil || resp.NextPage == 0 {
+			return infos, nil
+		}
+		opt.Page = resp.NextPage
+	}
+}
+
+// DeleteGist deletes the gist from GitHub.
+func (c *Client) DeleteGist(ctx context.Context, id string) error {
+	err := c.Retry.run(ctx, func(attempt int) error {
+		resp, err := c.gist.Delete(ctx, id)
+		if attempt > 0 && resp != nil && resp.StatusCode == http.StatusNotFound {
+			// The previous attempt went through before failing.
+			return nil
+		}
+		return err
+	}, classifyAPI(true))
+	return errors.Wrap(err, "when deleting gist")
+}
+
+func newInfo(g *github.Gist) Info {
+	info := Info{
+		ID: g.GetID(),
+		Owner: Owner{
+			Login: g.GetOwner().GetLogin(),
+			Name:  g.GetOwner().GetName(),
+			Email: g.GetOwner().GetEmail(),
+		},
+		HTMLURL: g.GetHTMLURL(),
+		GitURL:  g.GetGitPullURL(),
+
+		Description: g.GetDescription(),
+		Public:      g.GetPublic(),
+		CreatedAt:   g.GetCreatedAt(),
+		UpdatedAt:   g.GetUpdatedAt(),
+	}
+
+	for name, f := range g.Files {
+		info.Files = append(info.Files, FileInfo{
+			Name:   string(name),
+			Size:   f.GetSize(),
+			RawURL: f.GetRawURL(),
+		})
+	}
+	sort.Slice(info.Files, func(i, j int) bool {
+		return info.Files[i].Name < info.Files[j].Name
+	})
+
+	return info
 }
 
 // Option for CreateGist.
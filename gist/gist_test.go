@@ -2,7 +2,10 @@ package gist
 
 import (
 	"context"
+	"net/http"
+	"net/url"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	github "github.com/google/go-github/github"
@@ -10,13 +13,26 @@ import (
 )
 
 var testInfo = Info{
-	ID:      "johndoe",
-	Name:    "John Doe",
-	Email:   "jdoe@example.com",
+	ID: "abc123",
+	Owner: Owner{
+		Login: "johndoe",
+		Name:  "John Doe",
+		Email: "jdoe@example.com",
+	},
 	HTMLURL: "https://gist.github.com/johndoe/abc123",
 	GitURL:  "git@gist.github.com:abc123.git",
 }
 
+func newErrorResponse(code int) *github.ErrorResponse {
+	return &github.ErrorResponse{
+		Response: &http.Response{
+			StatusCode: code,
+			Header:     make(http.Header),
+			Request:    &http.Request{Method: http.MethodGet, URL: &url.URL{}},
+		},
+	}
+}
+
 func TestClient(t *testing.T) {
 	mockCtrl := gomock.NewController(t)
 	defer mockCtrl.Finish()
@@ -46,12 +62,13 @@ func TestClient(t *testing.T) {
 		},
 	}).Return(&github.Gist{
 		Owner: &github.User{
-			Login: &testInfo.ID,
-			Name:  &testInfo.Name,
-			Email: &testInfo.Email,
+			Login: &testInfo.Owner.Login,
+			Name:  &testInfo.Owner.Name,
+			Email: &testInfo.Owner.Email,
 		},
 		HTMLURL:    &testInfo.HTMLURL,
 		GitPullURL: &testInfo.GitURL,
+		ID:         &testInfo.ID,
 	}, nil, nil)
 
 	actual, err := c.CreateGist(ctx,
@@ -65,3 +82,155 @@ func TestClient(t *testing.T) {
 	assert.NoError(err)
 	assert.Equal(testInfo, actual)
 }
+
+func TestClientGetGist(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockGister := NewMockGister(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = mockGister
+
+	mockGister.EXPECT().Get(ctx, "abc123").Return(&github.Gist{
+		Owner: &github.User{
+			Login: &testInfo.Owner.Login,
+			Name:  &testInfo.Owner.Name,
+			Email: &testInfo.Owner.Email,
+		},
+		HTMLURL:    &testInfo.HTMLURL,
+		GitPullURL: &testInfo.GitURL,
+		ID:         &testInfo.ID,
+		Files: map[github.GistFilename]github.GistFile{
+			"b.png": {Size: github.Int(20), RawURL: github.String("https://gist.githubusercontent.com/johndoe/abc123/raw/0000/b.png")},
+			"a.png": {Size: github.Int(10), RawURL: github.String("https://gist.githubusercontent.com/johndoe/abc123/raw/0000/a.png")},
+		},
+	}, nil, nil)
+
+	actual, err := c.GetGist(ctx, "abc123")
+
+	expected := testInfo
+	expected.Files = []FileInfo{
+		{Name: "a.png", Size: 10, RawURL: "https://gist.githubusercontent.com/johndoe/abc123/raw/0000/a.png"},
+		{Name: "b.png", Size: 20, RawURL: "https://gist.githubusercontent.com/johndoe/abc123/raw/0000/b.png"},
+	}
+
+	assert := assert.New(t)
+	assert.NoError(err)
+	assert.Equal(expected, actual)
+
+	mockGister.EXPECT().Get(ctx, "unknown").Return(nil, nil, newErrorResponse(http.StatusNotFound))
+
+	_, err = c.GetGist(ctx, "unknown")
+	assert.Error(err)
+}
+
+func TestClientDeleteGist(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockGister := NewMockGister(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = mockGister
+
+	mockGister.EXPECT().Delete(ctx, "abc123").Return(nil, nil)
+	assert.NoError(t, c.DeleteGist(ctx, "abc123"))
+
+	mockGister.EXPECT().Delete(ctx, "abc123").Return(nil, newErrorResponse(http.StatusNotFound))
+	assert.Error(t, c.DeleteGist(ctx, "abc123"))
+}
+
+func TestClientListGists(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockGister := NewMockGister(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = mockGister
+
+	since := time.Date(2018, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	gomock.InOrder(
+		mockGister.EXPECT().List(ctx, "", &github.GistListOptions{
+			Since:       since,
+			ListOptions: github.ListOptions{PerPage: 100},
+		}).Return([]*github.Gist{
+			{
+				ID:          github.String("1"),
+				Description: github.String("design review"),
+				Public:      github.Bool(false),
+				Files:       map[github.GistFilename]github.GistFile{"logo.png": {}},
+			},
+			{
+				ID:          github.String("2"),
+				Description: github.String("design review"),
+				Public:      github.Bool(true),
+				Files:       map[github.GistFilename]github.GistFile{"logo.png": {}},
+			},
+		}, &github.Response{NextPage: 2}, nil),
+		mockGister.EXPECT().List(ctx, "", &github.GistListOptions{
+			Since:       since,
+			ListOptions: github.ListOptions{Page: 2, PerPage: 100},
+		}).Return([]*github.Gist{
+			{
+				ID:          github.String("3"),
+				Description: github.String("design review"),
+				Public:      github.Bool(false),
+				Files:       map[github.GistFilename]github.GistFile{"notes.md": {}},
+			},
+			{
+				ID:          github.String("4"),
+				Description: github.String("something else"),
+				Public:      github.Bool(false),
+				Files:       map[github.GistFilename]github.GistFile{"logo.png": {}},
+			},
+		}, &github.Response{}, nil),
+	)
+
+	actual, err := c.ListGists(ctx, ListFilter{
+		Since:       since,
+		Public:      github.Bool(false),
+		Filename:    "logo",
+		Description: "review",
+	})
+
+	assert := assert.New(t)
+	assert.NoError(err)
+	if assert.Len(actual, 1) {
+		assert.Equal("1", actual[0].ID)
+	}
+}
+
+func TestInfoPin(t *testing.T) {
+	info := testInfo
+	info.Files = []FileInfo{
+		{Name: "a.png", Size: 10, RawURL: "https://gist.githubusercontent.com/johndoe/abc123/raw/def456/a.png"},
+	}
+
+	pinned := info.Pin("0123ab")
+	assert.Equal(t, "0123ab", pinned.Commit)
+	assert.Equal(t, "https://gist.githubusercontent.com/johndoe/abc123/raw/0123ab/a.png", pinned.Files[0].RawURL)
+	assert.Equal(t, "https://gist.githubusercontent.com/johndoe/abc123/raw/def456/a.png", info.Files[0].RawURL)
+
+	assert.Equal(t, info, info.Pin(""))
+}
+
+func TestNewEnterpriseClient(t *testing.T) {
+	ctx := context.Background()
+
+	c, err := NewEnterpriseClient(ctx, "", "https://github.example.com/api/v3/",
+		"https://github.example.com/api/uploads/")
+	assert.NoError(t, err)
+	assert.NotNil(t, c.gist)
+	assert.Equal(t, DefaultRetryPolicy, c.Retry)
+	assert.Equal(t, "github.example.com", c.Host)
+	assert.Equal(t, "github.com", NewClient(ctx, "").Host)
+
+	_, err = NewEnterpriseClient(ctx, "", "://github.example.com", "")
+	assert.Error(t, err)
+}
@@ -2,26 +2,38 @@
 package gist
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
 	billy "gopkg.in/src-d/go-billy.v4"
 	"gopkg.in/src-d/go-billy.v4/memfs"
+	"gopkg.in/src-d/go-billy.v4/osfs"
 	git "gopkg.in/src-d/go-git.v4"
 	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
 	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
 	"gopkg.in/src-d/go-git.v4/storage"
+	"gopkg.in/src-d/go-git.v4/storage/filesystem"
 	"gopkg.in/src-d/go-git.v4/storage/memory"
 )
 
-var cloneFn = func(s storage.Storer, f billy.Filesystem, gitURL string) (
-	repoer, *git.Worktree, error) {
+var cloneFn = func(s storage.Storer, f billy.Filesystem, gitURL string,
+	auth transport.AuthMethod) (repoer, *git.Worktree, error) {
 
 	r, err := git.Clone(s, f, &git.CloneOptions{
-		URL: gitURL,
+		URL:  gitURL,
+		Auth: auth,
 	})
 	if err != nil {
 		return nil, nil, errors.Wrap(err, "when cloning a repo")
@@ -42,77 +54,545 @@ type repoer interface {
 }
 
 type Git struct {
-	info        Info
-	accessToken string
+	info Info
+	auth transport.AuthMethod
 
 	filesystem billy.Filesystem
-	storage    *memory.Storage
+	storage    storage.Storer
+	// tempDir holds the filesystem and the storage when they are on disk.
+	tempDir string
 
 	repo     repoer
 	worktree *git.Worktree
+
+	// added maps the gist filename to its source for the files added in
+	// this session, so that two sources never silently overwrite each other.
+	added map[string]string
+
+	// manifest maps flattened gist filenames to their original nested path.
+	manifest      map[string]string
+	manifestDirty bool
+
+	author    Person
+	committer *Person
+	signKey   *openpgp.Entity
+
+	// Retry is the policy for failed pushes, DefaultRetryPolicy unless
+	// changed.
+	Retry RetryPolicy
+
+	// Progress, when set, is informed while files are copied and pushed.
+	Progress Progress
+}
+
+// GitOption configures NewGit.
+type GitOption func(*gitOptions)
+
+type gitOptions struct {
+	onDisk    bool
+	tempDir   string
+	ssh       *SSHOptions
+	author    *Person
+	committer *Person
+	signKey   *openpgp.Entity
+}
+
+// OnDisk keeps the worktree and the objects in a temporary directory under
+// dir, or under the default directory for temporary files when dir is empty,
+// instead of in memory. Use it for large uploads and call Close afterwards to
+// remove the directory.
+func OnDisk(dir string) GitOption {
+	return func(o *gitOptions) {
+		o.onDisk = true
+		o.tempDir = dir
+	}
+}
+
+// Author of the commits made by Commit, by default the owner of the gist as
+// returned by the API, whose email is often empty. See Client.Author.
+func Author(p Person) GitOption {
+	return func(o *gitOptions) {
+		o.author = &p
+	}
+}
+
+// Committer of the commits made by Commit, by default the author.
+func Committer(p Person) GitOption {
+	return func(o *gitOptions) {
+		o.committer = &p
+	}
 }
 
-func NewGit(info Info, accessToken string) (*Git, error) {
-	f := memfs.New()
-	s := memory.NewStorage()
+// NewGit clones the gist described by info. The worktree and the objects are
+// kept in memory unless OnDisk is given.
+func NewGit(info Info, accessToken string, ops ...GitOption) (*Git, error) {
+	var o gitOptions
+	for _, op := range ops {
+		op(&o)
+	}
+
+	f, s, tempDir, err := newStorage(o)
+	if err != nil {
+		return nil, err
+	}
+
+	gitURL, auth, err := remote(info, accessToken, o)
+	if err != nil {
+		removeTempDir(tempDir)
+		return nil, err
+	}
 
-	r, w, err := cloneFn(s, f, info.GitURL)
+	r, w, err := cloneFn(s, f, gitURL, auth)
 	if err != nil {
+		removeTempDir(tempDir)
 		return nil, err
 	}
 
-	return &Git{
-		info:        info,
-		accessToken: accessToken,
+	g := &Git{
+		info: info,
+		auth: auth,
 
 		filesystem: f,
 		storage:    s,
+		tempDir:    tempDir,
 
 		repo:     r,
 		worktree: w,
-	}, nil
+
+		author:    Person{Name: info.Owner.Name, Email: info.Owner.Email},
+		committer: o.committer,
+		signKey:   o.signKey,
+
+		added: make(map[string]string),
+
+		Retry: DefaultRetryPolicy,
+	}
+
+	if o.author != nil {
+		g.author = *o.author
+	}
+
+	if err := g.loadManifest(); err != nil {
+		g.Close()
+		return nil, err
+	}
+
+	return g, nil
 }
 
+func newStorage(o gitOptions) (billy.Filesystem, storage.Storer, string, error) {
+	if !o.onDisk {
+		return memfs.New(), memory.NewStorage(), "", nil
+	}
+
+	dir, err := ioutil.TempDir(o.tempDir, "bgist-")
+	if err != nil {
+		return nil, nil, "", errors.Wrap(err, "when creating temporary directory")
+	}
+
+	s, err := filesystem.NewStorage(osfs.New(filepath.Join(dir, ".git")))
+	if err != nil {
+		removeTempDir(dir)
+		return nil, nil, "", errors.Wrap(err, "when creating storage")
+	}
+
+	return osfs.New(filepath.Join(dir, "worktree")), s, dir, nil
+}
+
+func removeTempDir(dir string) error {
+	if dir == "" {
+		return nil
+	}
+	return errors.Wrap(os.RemoveAll(dir), "when removing temporary directory")
+}
+
+// Close releases the on-disk worktree and storage created by OnDisk. It is a
+// no-op for the in-memory ones.
+func (g *Git) Close() error {
+	err := removeTempDir(g.tempDir)
+	g.tempDir = ""
+	return err
+}
+
+func (g *Git) loadManifest() error {
+	g.manifest = make(map[string]string)
+
+	f, err := g.filesystem.Open(ManifestFilename)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return errors.Wrap(err, "when opening the manifest")
+	}
+	defer f.Close()
+
+	return errors.Wrap(json.NewDecoder(f).Decode(&g.manifest), "when reading the manifest")
+}
+
+func (g *Git) writeManifest() error {
+	if !g.manifestDirty {
+		return nil
+	}
+
+	if len(g.manifest) == 0 {
+		if _, err := g.filesystem.Stat(ManifestFilename); os.IsNotExist(err) {
+			return nil
+		}
+		return g.Remove(ManifestFilename)
+	}
+
+	b, err := json.MarshalIndent(g.manifest, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "when encoding the manifest")
+	}
+
+	f, err := g.filesystem.Create(ManifestFilename)
+	if err != nil {
+		return errors.Wrap(err, "when creating the manifest")
+	}
+
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return errors.Wrap(err, "when writing the manifest")
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "when writing the manifest")
+	}
+
+	if _, err := g.worktree.Add(ManifestFilename); err != nil {
+		return errors.Wrap(err, "when adding the manifest to repo")
+	}
+
+	g.manifestDirty = false
+	return nil
+}
+
+// Manifest returns a copy of the mapping from flattened gist filenames to
+// their original nested path.
+func (g *Git) Manifest() map[string]string {
+	m := make(map[string]string, len(g.manifest))
+	for k, v := range g.manifest {
+		m[k] = v
+	}
+	return m
+}
+
+// Filenames returns the sorted names of the files in the gist without the
+// manifest.
+func (g *Git) Filenames() ([]string, error) {
+	fis, err := g.filesystem.ReadDir("")
+	if err != nil {
+		return nil, errors.Wrap(err, "when reading the worktree")
+	}
+
+	var names []string
+	for _, fi := range fis {
+		if fi.IsDir() || fi.Name() == ManifestFilename {
+			continue
+		}
+		names = append(names, fi.Name())
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
+
+// Resolve returns the gist filename of name, which is either a filename in
+// the gist or the original nested path recorded in the manifest.
+func (g *Git) Resolve(name string) (string, error) {
+	if _, err := g.filesystem.Stat(name); err == nil && name != ManifestFilename {
+		return name, nil
+	}
+
+	for flat, nested := range g.manifest {
+		if nested == name {
+			return flat, nil
+		}
+	}
+
+	return "", errors.Errorf("%s is not in the gist", name)
+}
+
+// Open opens the file of the gist for reading.
+func (g *Git) Open(filename string) (io.ReadCloser, error) {
+	f, err := g.filesystem.Open(filename)
+	return f, errors.Wrapf(err, "when opening %s", filename)
+}
+
+// Download writes the files of the gist, all when none is given, into dir.
+// Flattened files are written to their original nested path recorded in the
+// manifest.
+func (g *Git) Download(dir string, filenames ...string) error {
+	if len(filenames) == 0 {
+		var err error
+		if filenames, err = g.Filenames(); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range filenames {
+		rel := name
+		if nested, ok := g.manifest[name]; ok {
+			rel = nested
+		}
+
+		target, err := safeJoin(dir, rel)
+		if err != nil {
+			return err
+		}
+
+		if err := g.downloadFile(name, target); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *Git) downloadFile(filename, target string) error {
+	src, err := g.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return errors.Wrap(err, "when creating the target directory")
+	}
+
+	dst, err := os.Create(target)
+	if err != nil {
+		return errors.Wrap(err, "when creating the target file")
+	}
+
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return errors.Wrapf(err, "when writing %s", target)
+	}
+
+	return errors.Wrapf(dst.Close(), "when writing %s", target)
+}
+
+// safeJoin joins dir and the slash separated rel, refusing paths that would
+// escape dir.
+func safeJoin(dir, rel string) (string, error) {
+	clean := path.Clean("/" + rel)
+	if clean == "/" || clean != "/"+rel {
+		return "", errors.Errorf("refusing to write %s outside of %s", rel, dir)
+	}
+
+	return filepath.Join(dir, filepath.FromSlash(clean[1:])), nil
+}
+
+// Add copies the file at path into the gist. A directory is walked
+// recursively and every nested file is stored under its flattened path,
+// e.g. assets/a/logo.png becomes assets__a__logo.png.
 func (g *Git) Add(path string) error {
-	filename := filepath.Base(path)
+	uploads, err := expand(path)
+	if err != nil {
+		return err
+	}
 
-	newFile, err := g.filesystem.Create(filename)
+	for _, u := range uploads {
+		if err := g.AddUpload(u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddUpload copies the source of u into the gist as u.Name. The uploads are
+// usually prepared by Plan.
+func (g *Git) AddUpload(u Upload) error {
+	var err error
+	if u.Reader != nil {
+		err = g.AddReader(u.Name, u.Reader)
+	} else {
+		err = g.addFile(u.Name, u.Path)
+	}
 	if err != nil {
-		return errors.Wrap(err, "when creating a new file in filesystem")
+		return err
 	}
-	defer newFile.Close()
 
+	if u.Nested != "" && u.Nested != u.Name {
+		g.manifest[u.Name] = u.Nested
+		g.manifestDirty = true
+	}
+	return nil
+}
+
+func (g *Git) addFile(filename, path string) error {
 	sourceFile, err := os.Open(path)
 	if err != nil {
 		return errors.Wrap(err, "when openning the source")
 	}
 	defer sourceFile.Close()
 
-	if _, err = io.Copy(newFile, sourceFile); err != nil {
+	fi, err := sourceFile.Stat()
+	if err != nil {
+		return errors.Wrap(err, "when reading the source")
+	}
+
+	return g.addReader(filename, path, sourceFile, fi.Size())
+}
+
+// AddReader copies everything read from r into the gist as name. Empty input
+// is rejected since it cannot be checked before hand like a file.
+func (g *Git) AddReader(name string, r io.Reader) error {
+	br := bufio.NewReader(r)
+	if _, err := br.Peek(1); err == io.EOF {
+		return errors.Errorf("%s: input is empty", name)
+	} else if err != nil {
+		return errors.Wrap(err, "when reading the source")
+	}
+
+	return g.addReader(name, "-", br, -1)
+}
+
+func (g *Git) addReader(filename, source string, r io.Reader, total int64) error {
+	if filename == ManifestFilename {
+		return errors.Errorf("%s is reserved for bgist", ManifestFilename)
+	}
+
+	if prev, ok := g.added[filename]; ok && prev != source {
+		return errors.Errorf("%s and %s would both be uploaded as %s", prev, source, filename)
+	}
+	g.added[filename] = source
+
+	newFile, err := g.filesystem.Create(filename)
+	if err != nil {
+		return errors.Wrap(err, "when creating a new file in filesystem")
+	}
+	defer newFile.Close()
+
+	if g.Progress != nil {
+		r = &progressReader{r: r, progress: g.Progress, filename: filename, total: total}
+	}
+
+	if _, err = io.Copy(newFile, r); err != nil {
 		return errors.Wrap(err, "when copying the source to filesystem")
 	}
 
+	if g.Progress != nil {
+		g.Progress.Done(filename)
+	}
+
 	_, err = g.worktree.Add(filename)
 	return errors.Wrap(err, "when adding new file to repo")
 }
 
 func (g *Git) Remove(filename string) error {
+	if _, ok := g.manifest[filename]; ok {
+		delete(g.manifest, filename)
+		g.manifestDirty = true
+	}
+	delete(g.added, filename)
+
 	_, err := g.worktree.Remove(filename)
 	return errors.Wrap(err, "when removing file from repo")
 }
 
-func (g *Git) Commit(msg string) error {
-	_, err := g.worktree.Commit(msg, &git.CommitOptions{
+// Commit records the staged changes and returns the hash of the commit.
+func (g *Git) Commit(msg string) (string, error) {
+	if err := g.writeManifest(); err != nil {
+		return "", err
+	}
+
+	if err := g.sortIndex(); err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+
+	o := &git.CommitOptions{
 		Author: &object.Signature{
-			Name:  g.info.Name,
-			Email: g.info.Email,
-			When:  time.Now(),
+			Name:  g.author.Name,
+			Email: g.author.Email,
+			When:  now,
 		},
+	}
+	if g.committer != nil {
+		o.Committer = &object.Signature{
+			Name:  g.committer.Name,
+			Email: g.committer.Email,
+			When:  now,
+		}
+	}
+
+	h, err := g.worktree.Commit(msg, o)
+	if err != nil {
+		return "", errors.Wrap(err, "when commiting")
+	}
+
+	if g.signKey != nil {
+		if h, err = g.sign(h); err != nil {
+			return "", err
+		}
+	}
+
+	return h.String(), nil
+}
+
+// sortIndex sorts the index by name. go-git builds the tree in index order,
+// and a tree that is not sorted is rejected by remotes checking the pushed
+// objects, such as GitHub.
+func (g *Git) sortIndex() error {
+	idx, err := g.storage.Index()
+	if err != nil {
+		return errors.Wrap(err, "when reading the index")
+	}
+
+	sort.Slice(idx.Entries, func(i, j int) bool {
+		return idx.Entries[i].Name < idx.Entries[j].Name
 	})
-	return errors.Wrap(err, "when commiting")
+
+	return errors.Wrap(g.storage.SetIndex(idx), "when writing the index")
+}
+
+// remote returns the URL and the authentication to clone and push the gist
+// with, over SSH when requested by SSH.
+func remote(info Info, accessToken string, o gitOptions) (string, transport.AuthMethod, error) {
+	if o.ssh == nil {
+		return info.GitURL, basicAuth(info, accessToken), nil
+	}
+
+	host, repoPath, err := splitGitURL(info.GitURL)
+	if err != nil {
+		return "", nil, err
+	}
+	if o.ssh.Host != "" {
+		host = o.ssh.Host
+	}
+
+	user := o.ssh.User
+	if user == "" {
+		user = sshUser(host)
+	}
+
+	auth, err := sshAuth(host, user, o.ssh)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("%s@%s:%s", user, host, repoPath), auth, nil
+}
+
+// basicAuth authenticates the git transport with the access token, which is
+// required by GitHub Enterprise in private mode even for cloning.
+func basicAuth(info Info, accessToken string) transport.AuthMethod {
+	if accessToken == "" {
+		return nil
+	}
+	return &http.BasicAuth{Username: info.Owner.Login, Password: accessToken}
 }
 
 func (g *Git) Push() error {
-	auth := &http.BasicAuth{Username: g.info.ID, Password: g.accessToken}
-	return errors.Wrap(g.repo.Push(&git.PushOptions{Auth: auth}), "when pushing")
+	err := g.Retry.run(context.Background(), func(attempt int) error {
+		o := &git.PushOptions{Auth: g.auth}
+		if g.Progress != nil {
+			o.Progress = g.Progress
+		}
+		return pushed(g.repo.Push(o), attempt)
+	}, classifyPush)
+	return errors.Wrap(err, "when pushing")
 }
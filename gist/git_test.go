@@ -1,6 +1,11 @@
 package gist
 
 import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	gomock "github.com/golang/mock/gomock"
@@ -8,46 +13,93 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	billy "gopkg.in/src-d/go-billy.v4"
+	"gopkg.in/src-d/go-billy.v4/util"
 	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
 	"gopkg.in/src-d/go-git.v4/plumbing/transport/http"
 	"gopkg.in/src-d/go-git.v4/storage"
 )
 
-func TestGit(t *testing.T) {
-	mockCtrl := gomock.NewController(t)
-	defer mockCtrl.Finish()
-	mockRepoer := NewMockrepoer(mockCtrl)
+// cloneStub stands in for cloneFn so that NewGit works on an empty
+// repository instead of a clone of the gist.
+type cloneStub struct {
+	// repo is the repository of the last clone, url and auth what it was
+	// called with.
+	repo *git.Repository
+	url  string
+	auth transport.AuthMethod
 
-	var repo *git.Repository
+	// pusher, when set, is pushed to instead of repo, e.g. a Mockrepoer.
+	pusher repoer
+	// setup, when set, prepares the repository, e.g. with the files the
+	// gist already has.
+	setup func(w *git.Worktree, f billy.Filesystem) error
+}
 
-	cloneFn = func(s storage.Storer, f billy.Filesystem, gitURL string) (
-		repoer, *git.Worktree, error) {
+// stubClone replaces cloneFn with a cloneStub until restore is called.
+func stubClone() (stub *cloneStub, restore func()) {
+	orig := cloneFn
+	stub = &cloneStub{}
+	cloneFn = stub.clone
+	return stub, func() { cloneFn = orig }
+}
 
-		var err error
-		repo, err = git.Init(s, f)
-		if err != nil {
-			return nil, nil, errors.Wrap(err, "when initing a repo")
-		}
+func (c *cloneStub) clone(s storage.Storer, f billy.Filesystem, gitURL string,
+	auth transport.AuthMethod) (repoer, *git.Worktree, error) {
 
-		w, err := repo.Worktree()
-		if err != nil {
-			return nil, nil, errors.Wrap(err, "when creating worktree")
+	c.url, c.auth = gitURL, auth
+
+	repo, err := git.Init(s, f)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "when initing a repo")
+	}
+	c.repo = repo
+
+	w, err := repo.Worktree()
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "when creating worktree")
+	}
+
+	if c.setup != nil {
+		if err := c.setup(w, f); err != nil {
+			return nil, nil, err
 		}
+	}
 
-		return mockRepoer, w, nil
+	if c.pusher != nil {
+		return c.pusher, w, nil
 	}
+	return repo, w, nil
+}
+
+func TestGit(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockRepoer := NewMockrepoer(mockCtrl)
+
+	stub, restore := stubClone()
+	defer restore()
+	stub.pusher = mockRepoer
 
 	g, err := NewGit(testInfo, "secret")
 	assert.NoError(t, err)
+	assert.Equal(t, &http.BasicAuth{
+		Username: testInfo.Owner.Login,
+		Password: "secret",
+	}, stub.auth)
 
 	assert.NoError(t, g.Add("./testdata/test_1.txt"))
 	assert.NoError(t, g.Add("./testdata/test_2.txt"))
-	assert.NoError(t, g.Commit("adding new files"))
+	hash, err := g.Commit("adding new files")
+	assert.NoError(t, err)
+	assert.Len(t, hash, 40)
 	assert.NoError(t, g.Remove("test_1.txt"))
-	assert.NoError(t, g.Commit("removing test_1.txt"))
+	_, err = g.Commit("removing test_1.txt")
+	assert.NoError(t, err)
 
 	// Check if the changes are actually committed.
-	cIter, err := repo.Log(&git.LogOptions{})
+	cIter, err := stub.repo.Log(&git.LogOptions{})
 	require.NoError(t, err)
 	defer cIter.Close()
 
@@ -58,17 +110,240 @@ func TestGit(t *testing.T) {
 		c, actualErr := cIter.Next()
 		assert.NoError(t, actualErr)
 		assert.Equal(t, expected, c.Message)
-		assert.Equal(t, testInfo.Name, c.Author.Name)
-		assert.Equal(t, testInfo.Email, c.Author.Email)
+		assert.Equal(t, testInfo.Owner.Name, c.Author.Name)
+		assert.Equal(t, testInfo.Owner.Email, c.Author.Email)
 	}
 	_, err = cIter.Next()
 	assert.Error(t, err)
 
 	mockRepoer.EXPECT().Push(&git.PushOptions{
 		Auth: &http.BasicAuth{
-			Username: testInfo.ID,
-			Password: g.accessToken,
+			Username: testInfo.Owner.Login,
+			Password: "secret",
 		},
 	}).Return(nil)
 	assert.NoError(t, g.Push())
 }
+
+func TestGitAddDirectory(t *testing.T) {
+	_, restore := stubClone()
+	defer restore()
+
+	g, err := NewGit(testInfo, "secret")
+	require.NoError(t, err)
+
+	assert.NoError(t, g.Add("./testdata/nested/"))
+	_, err = g.Commit("adding a directory")
+	assert.NoError(t, err)
+
+	for name, content := range map[string]string{
+		"nested__a__test_1.txt": "nested a\n",
+		"nested__b__test_1.txt": "nested b\n",
+	} {
+		f, err := g.filesystem.Open(name)
+		require.NoError(t, err, name)
+		b, err := ioutil.ReadAll(f)
+		f.Close()
+		assert.NoError(t, err)
+		assert.Equal(t, content, string(b))
+	}
+
+	assert.Equal(t, map[string]string{
+		"nested__a__test_1.txt": "nested/a/test_1.txt",
+		"nested__b__test_1.txt": "nested/b/test_1.txt",
+	}, g.Manifest())
+
+	_, err = g.filesystem.Stat(ManifestFilename)
+	assert.NoError(t, err)
+
+	// A new session on the same filesystem picks up the recorded manifest.
+	assert.NoError(t, g.loadManifest())
+	assert.Len(t, g.Manifest(), 2)
+
+	// Two different sources must not silently overwrite each other.
+	assert.NoError(t, g.Add("./testdata/test_1.txt"))
+	assert.Error(t, g.Add("./testdata/nested/a/test_1.txt"))
+}
+
+func TestGitAddReader(t *testing.T) {
+	_, restore := stubClone()
+	defer restore()
+
+	g, err := NewGit(testInfo, "secret")
+	require.NoError(t, err)
+
+	assert.NoError(t, g.AddReader("dump.sql", strings.NewReader("SELECT 1;")))
+	assert.Error(t, g.AddReader("empty.sql", strings.NewReader("")))
+	_, err = g.Commit("adding from reader")
+	assert.NoError(t, err)
+
+	f, err := g.filesystem.Open("dump.sql")
+	require.NoError(t, err)
+	defer f.Close()
+
+	b, err := ioutil.ReadAll(f)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT 1;", string(b))
+}
+
+type testProgress struct {
+	bytes.Buffer
+	copied map[string][2]int64
+	done   []string
+}
+
+func (p *testProgress) Copied(filename string, copied, total int64) {
+	p.copied[filename] = [2]int64{copied, total}
+}
+
+func (p *testProgress) Done(filename string) {
+	p.done = append(p.done, filename)
+}
+
+func TestGitProgress(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockRepoer := NewMockrepoer(mockCtrl)
+
+	stub, restore := stubClone()
+	defer restore()
+	stub.pusher = mockRepoer
+
+	g, err := NewGit(testInfo, "secret")
+	require.NoError(t, err)
+
+	p := &testProgress{copied: make(map[string][2]int64)}
+	g.Progress = p
+
+	assert.NoError(t, g.Add("./testdata/test_1.txt"))
+	assert.NoError(t, g.AddReader("dump.sql", strings.NewReader("SELECT 1;")))
+
+	assert.Equal(t, map[string][2]int64{
+		"test_1.txt": {17, 17},
+		"dump.sql":   {9, -1},
+	}, p.copied)
+	assert.Equal(t, []string{"test_1.txt", "dump.sql"}, p.done)
+
+	mockRepoer.EXPECT().Push(&git.PushOptions{
+		Auth: &http.BasicAuth{
+			Username: testInfo.Owner.Login,
+			Password: "secret",
+		},
+		Progress: p,
+	}).Return(nil)
+	assert.NoError(t, g.Push())
+}
+
+func TestGitOnDisk(t *testing.T) {
+	_, restore := stubClone()
+	defer restore()
+
+	dir, err := ioutil.TempDir("", "bgist-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	g, err := NewGit(testInfo, "secret", OnDisk(dir))
+	require.NoError(t, err)
+
+	assert.NoError(t, g.Add("./testdata/test_1.txt"))
+	_, err = g.Commit("adding on disk")
+	assert.NoError(t, err)
+
+	_, err = os.Stat(filepath.Join(g.tempDir, "worktree", "test_1.txt"))
+	assert.NoError(t, err)
+	_, err = os.Stat(filepath.Join(g.tempDir, ".git", "objects"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, g.Close())
+
+	entries, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, entries)
+}
+
+func TestGitDownload(t *testing.T) {
+	_, restore := stubClone()
+	defer restore()
+
+	g, err := NewGit(testInfo, "secret")
+	require.NoError(t, err)
+
+	assert.NoError(t, g.Add("./testdata/test_1.txt"))
+	assert.NoError(t, g.Add("./testdata/nested"))
+	_, err = g.Commit("adding files")
+	assert.NoError(t, err)
+
+	names, err := g.Filenames()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"nested__a__test_1.txt", "nested__b__test_1.txt", "test_1.txt"}, names)
+
+	name, err := g.Resolve("nested/b/test_1.txt")
+	assert.NoError(t, err)
+	assert.Equal(t, "nested__b__test_1.txt", name)
+
+	_, err = g.Resolve("missing.txt")
+	assert.Error(t, err)
+
+	dir, err := ioutil.TempDir("", "bgist-test-")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	assert.NoError(t, g.Download(dir))
+
+	for p, expected := range map[string]string{
+		"test_1.txt":          "this is a test 1\n",
+		"nested/a/test_1.txt": "nested a\n",
+		"nested/b/test_1.txt": "nested b\n",
+	} {
+		b, err := ioutil.ReadFile(filepath.Join(dir, filepath.FromSlash(p)))
+		assert.NoError(t, err, p)
+		assert.Equal(t, expected, string(b), p)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, ManifestFilename))
+	assert.True(t, os.IsNotExist(err), "the manifest is not downloaded")
+
+	g.manifest["evil.txt"] = "../evil.txt"
+	assert.Error(t, g.Download(dir, "evil.txt"))
+}
+
+func TestGitCommitSortsTree(t *testing.T) {
+	stub, restore := stubClone()
+	defer restore()
+	stub.setup = func(w *git.Worktree, f billy.Filesystem) error {
+		return util.WriteFile(f, "zzz.txt", []byte("existing\n"), 0644)
+	}
+
+	g, err := NewGit(testInfo, "secret")
+	require.NoError(t, err)
+
+	// The existing file is in the index before the new ones and the
+	// manifest is added last, although it sorts first.
+	_, err = g.worktree.Add("zzz.txt")
+	require.NoError(t, err)
+	assert.NoError(t, g.Add("./testdata/test_1.txt"))
+	assert.NoError(t, g.Add("./testdata/nested/"))
+
+	hash, err := g.Commit("adding files")
+	require.NoError(t, err)
+
+	c, err := stub.repo.CommitObject(plumbing.NewHash(hash))
+	require.NoError(t, err)
+	tree, err := c.Tree()
+	require.NoError(t, err)
+
+	var names []string
+	for _, e := range tree.Entries {
+		names = append(names, e.Name)
+	}
+	assert.Equal(t, []string{
+		ManifestFilename,
+		"nested__a__test_1.txt",
+		"nested__b__test_1.txt",
+		"test_1.txt",
+		"zzz.txt",
+	}, names)
+
+	_, err = tree.FindEntry(ManifestFilename)
+	assert.NoError(t, err)
+}
@@ -0,0 +1,222 @@
+package gist
+
+import (
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/utils/merkletrie"
+)
+
+// Revision is a commit of the gist.
+type Revision struct {
+	Hash    string
+	Author  string
+	Email   string
+	When    time.Time
+	Message string
+	Changes []Change
+}
+
+// Change is a file added, modified, or deleted by a revision.
+type Change struct {
+	Action   string
+	Filename string
+}
+
+// History returns the revisions of the gist, newest first.
+func (g *Git) History() ([]Revision, error) {
+	iter, err := g.repo.Log(&git.LogOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "when reading the history")
+	}
+	defer iter.Close()
+
+	var revs []Revision
+	err = iter.ForEach(func(c *object.Commit) error {
+		rev, err := newRevision(c)
+		if err != nil {
+			return err
+		}
+		revs = append(revs, rev)
+		return nil
+	})
+
+	return revs, err
+}
+
+// Show returns the revision identified by a full or abbreviated commit hash
+// and the files of the gist at that revision.
+func (g *Git) Show(hash string) (Revision, []FileInfo, error) {
+	c, err := g.commit(hash)
+	if err != nil {
+		return Revision{}, nil, err
+	}
+
+	rev, err := newRevision(c)
+	if err != nil {
+		return Revision{}, nil, err
+	}
+
+	tree, err := c.Tree()
+	if err != nil {
+		return Revision{}, nil, errors.Wrap(err, "when reading the revision")
+	}
+
+	var files []FileInfo
+	err = tree.Files().ForEach(func(f *object.File) error {
+		if f.Name != ManifestFilename {
+			files = append(files, FileInfo{Name: f.Name, Size: int(f.Size)})
+		}
+		return nil
+	})
+
+	return rev, files, errors.Wrap(err, "when reading the revision")
+}
+
+// Restore puts the files of the gist back to the revision identified by a
+// full or abbreviated commit hash. The result is staged, call Commit and Push
+// to publish it as a new revision.
+func (g *Git) Restore(hash string) error {
+	c, err := g.commit(hash)
+	if err != nil {
+		return err
+	}
+
+	tree, err := c.Tree()
+	if err != nil {
+		return errors.Wrap(err, "when reading the revision")
+	}
+
+	current, err := g.filesystem.ReadDir("")
+	if err != nil {
+		return errors.Wrap(err, "when reading the worktree")
+	}
+
+	for _, fi := range current {
+		if fi.IsDir() {
+			continue
+		}
+		if _, err := g.worktree.Remove(fi.Name()); err != nil {
+			return errors.Wrap(err, "when removing file from repo")
+		}
+	}
+
+	err = tree.Files().ForEach(func(f *object.File) error {
+		r, err := f.Reader()
+		if err != nil {
+			return errors.Wrapf(err, "when reading %s", f.Name)
+		}
+		defer r.Close()
+
+		return g.restoreFile(f.Name, r)
+	})
+	if err != nil {
+		return err
+	}
+
+	g.added = make(map[string]string)
+	g.manifestDirty = false
+	return g.loadManifest()
+}
+
+func (g *Git) restoreFile(filename string, r io.Reader) error {
+	f, err := g.filesystem.Create(filename)
+	if err != nil {
+		return errors.Wrap(err, "when creating a new file in filesystem")
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return errors.Wrapf(err, "when restoring %s", filename)
+	}
+
+	_, err = g.worktree.Add(filename)
+	return errors.Wrap(err, "when adding new file to repo")
+}
+
+// commit finds the commit in the history whose hash starts with hash.
+func (g *Git) commit(hash string) (*object.Commit, error) {
+	hash = strings.ToLower(hash)
+	if len(hash) < 4 {
+		return nil, errors.Errorf("revision %q is too short", hash)
+	}
+
+	iter, err := g.repo.Log(&git.LogOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "when reading the history")
+	}
+	defer iter.Close()
+
+	var found *object.Commit
+	err = iter.ForEach(func(c *object.Commit) error {
+		if !strings.HasPrefix(c.Hash.String(), hash) {
+			return nil
+		}
+		if found != nil {
+			return errors.Errorf("revision %s is ambiguous", hash)
+		}
+		found = c
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if found == nil {
+		return nil, errors.Errorf("revision %s is not in the history", hash)
+	}
+	return found, nil
+}
+
+func newRevision(c *object.Commit) (Revision, error) {
+	rev := Revision{
+		Hash:    c.Hash.String(),
+		Author:  c.Author.Name,
+		Email:   c.Author.Email,
+		When:    c.Author.When,
+		Message: strings.TrimSpace(c.Message),
+	}
+
+	tree, err := c.Tree()
+	if err != nil {
+		return Revision{}, errors.Wrap(err, "when reading the revision")
+	}
+
+	var parentTree *object.Tree
+	if c.NumParents() > 0 {
+		parent, err := c.Parent(0)
+		if err != nil {
+			return Revision{}, errors.Wrap(err, "when reading the parent revision")
+		}
+		if parentTree, err = parent.Tree(); err != nil {
+			return Revision{}, errors.Wrap(err, "when reading the parent revision")
+		}
+	}
+
+	changes, err := object.DiffTree(parentTree, tree)
+	if err != nil {
+		return Revision{}, errors.Wrap(err, "when comparing revisions")
+	}
+
+	for _, ch := range changes {
+		action, err := ch.Action()
+		if err != nil {
+			return Revision{}, errors.Wrap(err, "when comparing revisions")
+		}
+
+		switch action {
+		case merkletrie.Insert:
+			rev.Changes = append(rev.Changes, Change{Action: "added", Filename: ch.To.Name})
+		case merkletrie.Delete:
+			rev.Changes = append(rev.Changes, Change{Action: "deleted", Filename: ch.From.Name})
+		default:
+			rev.Changes = append(rev.Changes, Change{Action: "modified", Filename: ch.To.Name})
+		}
+	}
+
+	return rev, nil
+}
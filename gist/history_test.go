@@ -0,0 +1,71 @@
+package gist
+
+import (
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGitHistory(t *testing.T) {
+	_, restore := stubClone()
+	defer restore()
+
+	g, err := NewGit(testInfo, "secret")
+	require.NoError(t, err)
+
+	require.NoError(t, g.Add("./testdata/test_1.txt"))
+	first, err := g.Commit("first")
+	require.NoError(t, err)
+
+	require.NoError(t, g.Add("./testdata/test_2.txt"))
+	require.NoError(t, g.Remove("test_1.txt"))
+	_, err = g.Commit("second")
+	require.NoError(t, err)
+
+	revs, err := g.History()
+	require.NoError(t, err)
+	require.Len(t, revs, 2)
+
+	assert.Equal(t, "second", revs[0].Message)
+	assert.Equal(t, testInfo.Owner.Name, revs[0].Author)
+	assert.ElementsMatch(t, []Change{
+		{Action: "deleted", Filename: "test_1.txt"},
+		{Action: "added", Filename: "test_2.txt"},
+	}, revs[0].Changes)
+
+	assert.Equal(t, first, revs[1].Hash)
+	assert.Equal(t, []Change{{Action: "added", Filename: "test_1.txt"}}, revs[1].Changes)
+
+	rev, files, err := g.Show(first[:7])
+	require.NoError(t, err)
+	assert.Equal(t, first, rev.Hash)
+	assert.Equal(t, []FileInfo{{Name: "test_1.txt", Size: 17}}, files)
+
+	_, _, err = g.Show("0000000")
+	assert.Error(t, err)
+
+	require.NoError(t, g.Restore(first[:7]))
+	_, err = g.Commit("restore")
+	require.NoError(t, err)
+
+	names, err := g.Filenames()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test_1.txt"}, names)
+
+	f, err := g.Open("test_1.txt")
+	require.NoError(t, err)
+	defer f.Close()
+	b, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "this is a test 1\n", string(b))
+
+	revs, err = g.History()
+	require.NoError(t, err)
+	require.Len(t, revs, 3)
+	assert.ElementsMatch(t, []Change{
+		{Action: "added", Filename: "test_1.txt"},
+		{Action: "deleted", Filename: "test_2.txt"},
+	}, revs[0].Changes)
+}
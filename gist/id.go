@@ -0,0 +1,38 @@
+package gist
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var idPattern = regexp.MustCompile(`^[0-9A-Za-z]+$`)
+
+// ParseID returns the gist ID of v which is either an ID or a gist URL, e.g.
+// https://gist.github.com/johndoe/abc123, https://gist.github.com/abc123.git,
+// or git@gist.github.com:abc123.git.
+func ParseID(v string) (string, error) {
+	id := v
+
+	switch {
+	case strings.Contains(v, "://"):
+		u, err := url.Parse(v)
+		if err != nil {
+			return "", errors.Wrapf(err, "when parsing gist URL %s", v)
+		}
+		id = path.Base(strings.TrimSuffix(u.Path, "/"))
+	case strings.Contains(v, ":"):
+		// scp-like git URL
+		id = path.Base(v[strings.LastIndex(v, ":")+1:])
+	}
+
+	id = strings.TrimSuffix(id, ".git")
+
+	if !idPattern.MatchString(id) {
+		return "", errors.Errorf("%s is not a gist ID or URL", v)
+	}
+	return id, nil
+}
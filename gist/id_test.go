@@ -0,0 +1,27 @@
+package gist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseID(t *testing.T) {
+	for _, v := range []string{
+		"abc123",
+		"https://gist.github.com/johndoe/abc123",
+		"https://gist.github.com/johndoe/abc123/",
+		"https://gist.github.com/abc123.git",
+		"git@gist.github.com:abc123.git",
+	} {
+		id, err := ParseID(v)
+		require.NoError(t, err, v)
+		assert.Equal(t, "abc123", id, v)
+	}
+
+	for _, v := range []string{"", "https://gist.github.com/", "abc 123", "../abc123"} {
+		_, err := ParseID(v)
+		assert.Error(t, err, v)
+	}
+}
@@ -47,3 +47,44 @@ func (m *MockGister) Create(arg0 context.Context, arg1 *github.Gist) (*github.Gi
 func (mr *MockGisterMockRecorder) Create(arg0, arg1 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockGister)(nil).Create), arg0, arg1)
 }
+
+// Get mocks base method
+func (m *MockGister) Get(arg0 context.Context, arg1 string) (*github.Gist, *github.Response, error) {
+	ret := m.ctrl.Call(m, "Get", arg0, arg1)
+	ret0, _ := ret[0].(*github.Gist)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Get indicates an expected call of Get
+func (mr *MockGisterMockRecorder) Get(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockGister)(nil).Get), arg0, arg1)
+}
+
+// Delete mocks base method
+func (m *MockGister) Delete(arg0 context.Context, arg1 string) (*github.Response, error) {
+	ret := m.ctrl.Call(m, "Delete", arg0, arg1)
+	ret0, _ := ret[0].(*github.Response)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete
+func (mr *MockGisterMockRecorder) Delete(arg0, arg1 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockGister)(nil).Delete), arg0, arg1)
+}
+
+// List mocks base method
+func (m *MockGister) List(arg0 context.Context, arg1 string, arg2 *github.GistListOptions) ([]*github.Gist, *github.Response, error) {
+	ret := m.ctrl.Call(m, "List", arg0, arg1, arg2)
+	ret0, _ := ret[0].([]*github.Gist)
+	ret1, _ := ret[1].(*github.Response)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List
+func (mr *MockGisterMockRecorder) List(arg0, arg1, arg2 interface{}) *gomock.Call {
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockGister)(nil).List), arg0, arg1, arg2)
+}
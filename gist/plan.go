@@ -0,0 +1,301 @@
+package gist
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// RenameStrategy decides what Plan does when two sources would be uploaded
+// under the same gist filename.
+type RenameStrategy string
+
+const (
+	// RenameError reports the duplicate as a problem.
+	RenameError RenameStrategy = "error"
+	// RenameSuffix appends -1, -2, ... before the extension of the later
+	// duplicates.
+	RenameSuffix RenameStrategy = "suffix"
+	// RenameHash appends the first 8 hex digits of the SHA-256 of the content
+	// before the extension of the later duplicates. Duplicates with identical
+	// content are uploaded only once.
+	RenameHash RenameStrategy = "hash"
+)
+
+// RenameStrategies lists the valid values of RenameStrategy.
+var RenameStrategies = []RenameStrategy{RenameError, RenameSuffix, RenameHash}
+
+// Upload is a single file that will be copied into the gist.
+type Upload struct {
+	// Name is the filename in the gist.
+	Name string
+	// Nested is the original slash separated path when the file was found
+	// by walking a directory, empty otherwise.
+	Nested string
+	// Path is the source on the local filesystem.
+	Path string
+	// Size of the source in bytes. It is unknown, i.e. 0, for a Reader.
+	Size int64
+	// Reader, when set, is copied instead of the file at Path.
+	Reader io.Reader
+}
+
+// ReaderUpload prepares r to be uploaded as name, e.g. for content piped
+// through stdin.
+func ReaderUpload(name string, r io.Reader) Upload {
+	return Upload{Name: name, Path: "-", Reader: r}
+}
+
+// ValidationError holds every problem Plan found in the upload set.
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%d problem(s) with the files to upload:\n  %s",
+		len(e.Problems), strings.Join(e.Problems, "\n  "))
+}
+
+// Plan stats, opens, and names every source in paths before anything is sent
+// to GitHub. Directories are expanded recursively. Empty or unreadable files
+// and, depending on strategy, duplicate gist filenames are reported together
+// as a *ValidationError. The readers, prepared with ReaderUpload, keep their
+// names and take part in the duplicate detection only.
+func Plan(paths []string, strategy RenameStrategy, readers ...Upload) ([]Upload, error) {
+	switch strategy {
+	case RenameError, RenameSuffix, RenameHash:
+	default:
+		return nil, errors.Errorf("unknown rename strategy %q", strategy)
+	}
+
+	var problems []string
+
+	candidates := append([]Upload(nil), readers...)
+	for _, p := range paths {
+		uploads, err := expand(p)
+		if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		candidates = append(candidates, uploads...)
+	}
+
+	var uploads []Upload
+	for _, u := range candidates {
+		if u.Reader != nil {
+			u, err := peekReader(u)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+			uploads = append(uploads, u)
+			continue
+		}
+
+		if err := checkReadable(u); err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		uploads = append(uploads, u)
+	}
+
+	uploads, dups := resolveDuplicates(uploads, strategy)
+	problems = append(problems, dups...)
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return uploads, nil
+}
+
+func expand(p string) ([]Upload, error) {
+	fi, err := os.Stat(p)
+	if err != nil {
+		return nil, errors.Wrap(err, "when reading the source")
+	}
+
+	if !fi.IsDir() {
+		return []Upload{{Name: filepath.Base(p), Path: p, Size: fi.Size()}}, nil
+	}
+
+	base := filepath.Base(filepath.Clean(p))
+
+	var uploads []Upload
+	err = filepath.Walk(p, func(f string, fi os.FileInfo, err error) error {
+		if err != nil {
+			return errors.Wrap(err, "when walking the source directory")
+		}
+
+		if !fi.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(p, f)
+		if err != nil {
+			return errors.Wrap(err, "when walking the source directory")
+		}
+
+		nested := filepath.ToSlash(filepath.Join(base, rel))
+		uploads = append(uploads, Upload{
+			Name:   FlattenPath(nested),
+			Nested: nested,
+			Path:   f,
+			Size:   fi.Size(),
+		})
+		return nil
+	})
+
+	return uploads, err
+}
+
+func checkReadable(u Upload) error {
+	if u.Name == ManifestFilename {
+		return errors.Errorf("%s: %s is reserved for bgist", u.Path, ManifestFilename)
+	}
+
+	f, err := os.Open(u.Path)
+	if err != nil {
+		return errors.Wrap(err, "when openning the source")
+	}
+	defer f.Close()
+
+	if u.Size == 0 {
+		return errors.Errorf("%s: file is empty", u.Path)
+	}
+
+	var b [1]byte
+	if _, err := f.Read(b[:]); err != nil {
+		return errors.Wrapf(err, "%s: file is unreadable", u.Path)
+	}
+	return nil
+}
+
+// peekReader reads the first byte of the reader of u so that empty input is
+// reported before the gist is created. The byte is put back in front of the
+// returned reader.
+func peekReader(u Upload) (Upload, error) {
+	var b [1]byte
+	n, err := io.ReadFull(u.Reader, b[:])
+	if err == io.EOF {
+		return u, errors.Errorf("%s: input for %s is empty", u.Path, u.Name)
+	}
+	if err != nil {
+		return u, errors.Wrapf(err, "%s: input for %s is unreadable", u.Path, u.Name)
+	}
+
+	u.Reader = io.MultiReader(bytes.NewReader(b[:n]), u.Reader)
+	return u, nil
+}
+
+// checkReaders peeks every reader of uploads, see peekReader, for uploads
+// that did not go through Plan.
+func checkReaders(uploads []Upload) ([]Upload, error) {
+	checked := make([]Upload, 0, len(uploads))
+
+	var problems []string
+	for _, u := range uploads {
+		if u.Reader != nil {
+			var err error
+			if u, err = peekReader(u); err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+		}
+		checked = append(checked, u)
+	}
+
+	if len(problems) > 0 {
+		return nil, &ValidationError{Problems: problems}
+	}
+	return checked, nil
+}
+
+func resolveDuplicates(uploads []Upload, strategy RenameStrategy) ([]Upload, []string) {
+	var (
+		problems []string
+		resolved []Upload
+	)
+
+	taken := make(map[string]Upload)
+	for _, u := range uploads {
+		u := u
+
+		prev, ok := taken[u.Name]
+		if !ok {
+			taken[u.Name] = u
+			resolved = append(resolved, u)
+			continue
+		}
+
+		if u.Reader != nil || prev.Reader != nil && strategy == RenameHash {
+			// A reader can be read only once, its content cannot be hashed.
+			problems = append(problems, fmt.Sprintf("%s and %s would both be uploaded as %s", prev.Path, u.Path, u.Name))
+			continue
+		}
+
+		switch strategy {
+		case RenameSuffix:
+			for i := 1; ; i++ {
+				name := insertSuffix(u.Name, fmt.Sprint(i))
+				if _, ok := taken[name]; !ok {
+					u.Name = name
+					break
+				}
+			}
+		case RenameHash:
+			sum, err := hashFile(u.Path)
+			if err != nil {
+				problems = append(problems, err.Error())
+				continue
+			}
+
+			name := insertSuffix(u.Name, sum)
+			if _, ok := taken[name]; ok || sameContent(prev, sum) {
+				// Identical content, no need to upload it twice.
+				continue
+			}
+			u.Name = name
+		default:
+			problems = append(problems, fmt.Sprintf("%s and %s would both be uploaded as %s", prev.Path, u.Path, u.Name))
+			continue
+		}
+
+		taken[u.Name] = u
+		resolved = append(resolved, u)
+	}
+
+	return resolved, problems
+}
+
+func sameContent(u Upload, sum string) bool {
+	other, err := hashFile(u.Path)
+	return err == nil && other == sum
+}
+
+func insertSuffix(name, suffix string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "-" + suffix + ext
+}
+
+func hashFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", errors.Wrap(err, "when openning the source")
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", errors.Wrapf(err, "%s: file is unreadable", p)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))[:8], nil
+}
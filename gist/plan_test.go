@@ -0,0 +1,77 @@
+package gist
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPlan(t *testing.T) {
+	uploads, err := Plan([]string{"./testdata/test_1.txt", "./testdata/nested"}, RenameError)
+	require.NoError(t, err)
+	assert.Equal(t, []Upload{
+		{Name: "test_1.txt", Path: "./testdata/test_1.txt", Size: 17},
+		{Name: "nested__a__test_1.txt", Nested: "nested/a/test_1.txt", Path: "testdata/nested/a/test_1.txt", Size: 9},
+		{Name: "nested__b__test_1.txt", Nested: "nested/b/test_1.txt", Path: "testdata/nested/b/test_1.txt", Size: 9},
+	}, uploads)
+
+	_, err = Plan([]string{
+		"./testdata/test_1.txt",
+		"./testdata/nested/a/test_1.txt",
+		"./testdata/empty.txt",
+		"./testdata/missing.txt",
+	}, RenameError)
+	require.IsType(t, &ValidationError{}, err)
+	assert.Len(t, err.(*ValidationError).Problems, 3)
+
+	_, err = Plan([]string{"./testdata/test_1.txt"}, RenameStrategy("unknown"))
+	assert.Error(t, err)
+}
+
+func TestPlanRename(t *testing.T) {
+	paths := []string{
+		"./testdata/test_1.txt",
+		"./testdata/nested/a/test_1.txt",
+		"./testdata/copy/test_1.txt",
+	}
+
+	uploads, err := Plan(paths, RenameSuffix)
+	require.NoError(t, err)
+	var names []string
+	for _, u := range uploads {
+		names = append(names, u.Name)
+	}
+	assert.Equal(t, []string{"test_1.txt", "test_1-1.txt", "test_1-2.txt"}, names)
+
+	uploads, err = Plan(paths, RenameHash)
+	require.NoError(t, err)
+	require.Len(t, uploads, 2, "identical content is uploaded once")
+	assert.Equal(t, "test_1.txt", uploads[0].Name)
+	assert.Regexp(t, `^test_1-[0-9a-f]{8}\.txt$`, uploads[1].Name)
+}
+
+func TestPlanReader(t *testing.T) {
+	stdin := ReaderUpload("test_1.txt", strings.NewReader("piped"))
+
+	uploads, err := Plan([]string{"./testdata/test_1.txt"}, RenameSuffix, stdin)
+	require.NoError(t, err)
+	require.Len(t, uploads, 2)
+	assert.Equal(t, "test_1.txt", uploads[0].Name, "the reader keeps its name")
+	assert.Equal(t, "test_1-1.txt", uploads[1].Name)
+
+	b, err := ioutil.ReadAll(uploads[0].Reader)
+	assert.NoError(t, err)
+	assert.Equal(t, "piped", string(b), "the peeked byte is kept")
+
+	_, err = Plan([]string{"./testdata/test_1.txt"}, RenameHash,
+		ReaderUpload("test_1.txt", strings.NewReader("piped")))
+	assert.Error(t, err)
+
+	_, err = Plan(nil, RenameError, ReaderUpload("e.bin", strings.NewReader("")))
+	if assert.IsType(t, &ValidationError{}, err) {
+		assert.Equal(t, []string{"-: input for e.bin is empty"}, err.(*ValidationError).Problems)
+	}
+}
@@ -0,0 +1,33 @@
+package gist
+
+import "io"
+
+// Progress receives updates while files are copied into the gist and while
+// the gist is pushed.
+type Progress interface {
+	// Copied is called while filename is copied with the number of bytes
+	// copied so far and the total, -1 when unknown, e.g. for a reader.
+	Copied(filename string, copied, total int64)
+	// Done is called when filename has been copied completely.
+	Done(filename string)
+	// Write receives the human readable push progress sent by the server
+	// through the sideband.
+	io.Writer
+}
+
+type progressReader struct {
+	r        io.Reader
+	progress Progress
+	filename string
+	copied   int64
+	total    int64
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 {
+		p.copied += int64(n)
+		p.progress.Copied(p.filename, p.copied, p.total)
+	}
+	return n, err
+}
@@ -0,0 +1,195 @@
+package gist
+
+import (
+	"context"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	githttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+// RetryPolicy controls how failed GitHub API calls and pushes are retried.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts. Values below 1 mean a
+	// single attempt.
+	Attempts int
+	// Backoff is the delay before the first retry. It doubles after every
+	// attempt and up to half of it is randomized.
+	Backoff time.Duration
+	// MaxBackoff caps the delay. When GitHub asks to wait longer, through
+	// Retry-After or X-RateLimit-Reset, the call is not retried at all.
+	MaxBackoff time.Duration
+}
+
+// DefaultRetryPolicy is used by NewClient and NewGit.
+var DefaultRetryPolicy = RetryPolicy{
+	Attempts:   3,
+	Backoff:    time.Second,
+	MaxBackoff: time.Minute,
+}
+
+var sleepFn = func(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+// run calls fn until it succeeds or runs out of attempts. classify tells
+// whether the error is worth another attempt and how long GitHub asked to
+// wait, 0 if it did not.
+func (p RetryPolicy) run(ctx context.Context, fn func(attempt int) error,
+	classify func(error) (bool, time.Duration)) error {
+
+	for attempt := 0; ; attempt++ {
+		err := fn(attempt)
+		if err == nil {
+			return nil
+		}
+
+		retry, wait := classify(err)
+		if !retry || attempt+1 >= p.Attempts {
+			return err
+		}
+
+		if wait <= 0 {
+			wait = p.backoff(attempt)
+		}
+		if p.MaxBackoff > 0 && wait > p.MaxBackoff {
+			return err
+		}
+
+		if err := sleepFn(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.Backoff << uint(attempt)
+	if p.MaxBackoff > 0 && d > p.MaxBackoff {
+		d = p.MaxBackoff
+	}
+
+	if half := int64(d / 2); half > 0 {
+		d = d/2 + time.Duration(rand.Int63n(half+1))
+	}
+	return d
+}
+
+// classifyAPI decides whether a failed API call is retried. A call that is
+// not idempotent, e.g. creating a gist, is retried only when GitHub surely
+// rejected it without processing, i.e. when it was rate limited.
+func classifyAPI(idempotent bool) func(error) (bool, time.Duration) {
+	return func(err error) (bool, time.Duration) {
+		switch e := err.(type) {
+		case *github.RateLimitError:
+			return true, time.Until(e.Rate.Reset.Time)
+		case *github.AbuseRateLimitError:
+			if e.RetryAfter != nil {
+				return true, *e.RetryAfter
+			}
+			return true, headerDelay(e.Response)
+		case *github.AcceptedError:
+			return idempotent, 0
+		case *github.ErrorResponse:
+			switch code := e.Response.StatusCode; {
+			case code == http.StatusTooManyRequests:
+				return true, headerDelay(e.Response)
+			case code >= http.StatusInternalServerError:
+				return idempotent, headerDelay(e.Response)
+			default:
+				return false, 0
+			}
+		}
+
+		if err == context.Canceled || err == context.DeadlineExceeded {
+			return false, 0
+		}
+
+		// Network errors may happen after GitHub has processed the call.
+		return idempotent, 0
+	}
+}
+
+// classifyPush decides whether a failed push is retried. Pushing the same
+// commit again is harmless, but only transport failures, like timeouts,
+// dropped connections, and 5xx responses, may go away by themselves. Anything
+// else, e.g. a rejected non-fast-forward update or an unpack error reported
+// by the remote, fails the same way again.
+func classifyPush(err error) (bool, time.Duration) {
+	err = errors.Cause(err)
+
+	switch err {
+	case io.EOF, io.ErrUnexpectedEOF:
+		return true, 0
+	}
+
+	if ue, ok := err.(*plumbing.UnexpectedError); ok {
+		if he, ok := ue.Err.(*githttp.Err); ok {
+			return he.StatusCode() == http.StatusTooManyRequests ||
+				he.StatusCode() >= http.StatusInternalServerError, headerDelay(he.Response)
+		}
+		err = ue.Err
+	}
+
+	if _, ok := err.(net.Error); ok {
+		return true, 0
+	}
+
+	msg := err.Error()
+	for _, transient := range []string{"connection reset by peer", "broken pipe", "i/o timeout"} {
+		if strings.Contains(msg, transient) {
+			return true, 0
+		}
+	}
+	return false, 0
+}
+
+// pushed treats an up-to-date remote after a retry as success because the
+// previous attempt went through before failing.
+func pushed(err error, attempt int) error {
+	if err == git.NoErrAlreadyUpToDate && attempt > 0 {
+		return nil
+	}
+	return err
+}
+
+// headerDelay reads how long GitHub asked to wait from Retry-After or, when
+// the rate limit is exhausted, X-RateLimit-Reset.
+func headerDelay(r *http.Response) time.Duration {
+	if r == nil {
+		return 0
+	}
+
+	if v := r.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.Atoi(v); err == nil {
+			return time.Duration(secs) * time.Second
+		}
+		if t, err := http.ParseTime(v); err == nil {
+			return time.Until(t)
+		}
+	}
+
+	if r.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(r.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Until(time.Unix(reset, 0))
+		}
+	}
+
+	return 0
+}
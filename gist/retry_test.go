@@ -0,0 +1,148 @@
+package gist
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	github "github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	githttp "gopkg.in/src-d/go-git.v4/plumbing/transport/http"
+)
+
+func stubSleep() (*[]time.Duration, func()) {
+	var slept []time.Duration
+
+	orig := sleepFn
+	sleepFn = func(_ context.Context, d time.Duration) error {
+		slept = append(slept, d)
+		return nil
+	}
+
+	return &slept, func() { sleepFn = orig }
+}
+
+func TestRetryAPI(t *testing.T) {
+	slept, restore := stubSleep()
+	defer restore()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockGister := NewMockGister(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = mockGister
+
+	limited := newErrorResponse(http.StatusTooManyRequests)
+	limited.Response.Header.Set("Retry-After", "7")
+
+	gomock.InOrder(
+		mockGister.EXPECT().Get(ctx, "abc123").Return(nil, nil, newErrorResponse(http.StatusBadGateway)),
+		mockGister.EXPECT().Get(ctx, "abc123").Return(nil, nil, limited),
+		mockGister.EXPECT().Get(ctx, "abc123").Return(&github.Gist{ID: github.String("abc123")}, nil, nil),
+	)
+
+	info, err := c.GetGist(ctx, "abc123")
+	require.NoError(t, err)
+	assert.Equal(t, "abc123", info.ID)
+	require.Len(t, *slept, 2)
+	assert.InDelta(t, float64(time.Second), float64((*slept)[0]), float64(time.Second/2))
+	assert.Equal(t, 7*time.Second, (*slept)[1])
+
+	// Creating a gist is not retried when it might have gone through.
+	*slept = nil
+	mockGister.EXPECT().Create(ctx, gomock.Any()).Return(nil, nil, newErrorResponse(http.StatusBadGateway))
+	_, err = c.CreateGist(ctx)
+	assert.Error(t, err)
+	assert.Empty(t, *slept)
+
+	// ... but it is when GitHub rejected it because of the rate limit.
+	gomock.InOrder(
+		mockGister.EXPECT().Create(ctx, gomock.Any()).Return(nil, nil, &github.AbuseRateLimitError{}),
+		mockGister.EXPECT().Create(ctx, gomock.Any()).Return(&github.Gist{}, nil, nil),
+	)
+	_, err = c.CreateGist(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, *slept, 1)
+
+	// Client errors are never retried.
+	mockGister.EXPECT().Get(ctx, "unknown").Return(nil, nil, newErrorResponse(http.StatusNotFound))
+	_, err = c.GetGist(ctx, "unknown")
+	assert.Error(t, err)
+
+	// GitHub asking to wait longer than MaxBackoff gives up.
+	*slept = nil
+	c.Retry.MaxBackoff = 5 * time.Second
+	mockGister.EXPECT().Get(ctx, "abc123").Return(nil, nil, limited)
+	_, err = c.GetGist(ctx, "abc123")
+	assert.Error(t, err)
+	assert.Empty(t, *slept)
+}
+
+func TestRetryPush(t *testing.T) {
+	slept, restore := stubSleep()
+	defer restore()
+
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+	mockRepoer := NewMockrepoer(mockCtrl)
+
+	g := &Git{info: testInfo, repo: mockRepoer, Retry: DefaultRetryPolicy}
+
+	gomock.InOrder(
+		mockRepoer.EXPECT().Push(gomock.Any()).Return(errors.New("connection reset by peer")),
+		mockRepoer.EXPECT().Push(gomock.Any()).Return(git.NoErrAlreadyUpToDate),
+	)
+	assert.NoError(t, g.Push())
+	assert.Len(t, *slept, 1)
+
+	mockRepoer.EXPECT().Push(gomock.Any()).Return(transport.ErrAuthorizationFailed)
+	assert.Error(t, g.Push())
+	assert.Len(t, *slept, 1)
+}
+
+func pushResponseError(code int) error {
+	return githttp.NewErr(&http.Response{
+		StatusCode: code,
+		Header:     make(http.Header),
+		Request:    &http.Request{Method: http.MethodPost, URL: &url.URL{}},
+	})
+}
+
+func TestClassifyPush(t *testing.T) {
+	for _, tc := range []struct {
+		err   error
+		retry bool
+	}{
+		{errors.New("read tcp 10.0.0.1:4242->140.82.112.3:443: read: connection reset by peer"), true},
+		{&net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}, true},
+		{&url.Error{Op: "Post", URL: "https://gist.github.com/abc123.git", Err: context.DeadlineExceeded}, true},
+		{io.ErrUnexpectedEOF, true},
+		{errors.Wrap(io.EOF, "when reading the response"), true},
+		{pushResponseError(http.StatusBadGateway), true},
+		{pushResponseError(http.StatusTooManyRequests), true},
+		{pushResponseError(http.StatusBadRequest), false},
+		{errors.New("non-fast-forward update: refs/heads/master"), false},
+		{errors.New("unpack error: unpack-objects abnormal exit"), false},
+		{transport.ErrAuthenticationRequired, false},
+		{transport.ErrAuthorizationFailed, false},
+		{transport.ErrRepositoryNotFound, false},
+		{transport.ErrInvalidAuthMethod, false},
+		{context.Canceled, false},
+		{errors.New("something unexpected"), false},
+	} {
+		retry, _ := classifyPush(tc.err)
+		assert.Equal(t, tc.retry, retry, "%v", tc.err)
+	}
+}
@@ -0,0 +1,127 @@
+package gist
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/openpgp"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+	"gopkg.in/src-d/go-git.v4/plumbing/storer"
+)
+
+// ReadSignKey reads the first private key of the armored key ring in r.
+// passphrase is called only when the key is encrypted.
+func ReadSignKey(r io.Reader, passphrase func() (string, error)) (*openpgp.Entity, error) {
+	keys, err := openpgp.ReadArmoredKeyRing(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "when reading the signing key")
+	}
+
+	for _, k := range keys {
+		if k.PrivateKey == nil {
+			continue
+		}
+
+		if k.PrivateKey.Encrypted {
+			p, err := passphrase()
+			if err != nil {
+				return nil, err
+			}
+			if err := k.PrivateKey.Decrypt([]byte(p)); err != nil {
+				return nil, errors.Wrap(err, "when decrypting the signing key")
+			}
+		}
+		return k, nil
+	}
+
+	return nil, errors.New("the key ring has no private key")
+}
+
+// Sign signs the commits made by Commit with key, so that GitHub shows them
+// as verified when the public key belongs to the committer.
+func Sign(key *openpgp.Entity) GitOption {
+	return func(o *gitOptions) {
+		o.signKey = key
+	}
+}
+
+// sign replaces the commit h, which must be HEAD, with a copy carrying the
+// detached signature of key and returns the hash of the copy.
+func (g *Git) sign(h plumbing.Hash) (plumbing.Hash, error) {
+	c, err := object.GetCommit(g.storage, h)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "when reading the commit to sign")
+	}
+
+	unsigned := &plumbing.MemoryObject{}
+	if err := c.Encode(unsigned); err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "when encoding the commit to sign")
+	}
+
+	r, err := unsigned.Reader()
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "when encoding the commit to sign")
+	}
+	payload, err := ioutil.ReadAll(r)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "when encoding the commit to sign")
+	}
+
+	var sig strings.Builder
+	if err := openpgp.ArmoredDetachSign(&sig, g.signKey, bytes.NewReader(payload), nil); err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "when signing the commit")
+	}
+
+	signed := g.storage.NewEncodedObject()
+	signed.SetType(plumbing.CommitObject)
+
+	w, err := signed.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "when writing the signed commit")
+	}
+	if _, err := w.Write(withSignature(payload, sig.String())); err != nil {
+		w.Close()
+		return plumbing.ZeroHash, errors.Wrap(err, "when writing the signed commit")
+	}
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "when writing the signed commit")
+	}
+
+	signedHash, err := g.storage.SetEncodedObject(signed)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "when storing the signed commit")
+	}
+
+	head, err := storer.ResolveReference(g.storage, plumbing.HEAD)
+	if err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "when resolving HEAD")
+	}
+
+	ref := plumbing.NewHashReference(head.Name(), signedHash)
+	if err := g.storage.SetReference(ref); err != nil {
+		return plumbing.ZeroHash, errors.Wrap(err, "when moving HEAD to the signed commit")
+	}
+
+	return signedHash, nil
+}
+
+// withSignature inserts the gpgsig header, as written by git, between the
+// headers and the message of the encoded commit payload.
+func withSignature(payload []byte, sig string) []byte {
+	i := bytes.Index(payload, []byte("\n\n"))
+	if i < 0 {
+		i = len(payload)
+	}
+
+	header := "\ngpgsig " + strings.Replace(strings.TrimSuffix(sig, "\n"), "\n", "\n ", -1)
+
+	var b bytes.Buffer
+	b.Write(payload[:i])
+	b.WriteString(header)
+	b.Write(payload[i:])
+	return b.Bytes()
+}
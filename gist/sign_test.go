@@ -0,0 +1,82 @@
+package gist
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+)
+
+func readTestKey(t *testing.T, name string) string {
+	b, err := ioutil.ReadFile(filepath.Join("testdata", "sign", name))
+	require.NoError(t, err)
+	return string(b)
+}
+
+func TestReadSignKey(t *testing.T) {
+	private := readTestKey(t, "private.asc")
+
+	prompted := 0
+	passphrase := func(p string) func() (string, error) {
+		return func() (string, error) {
+			prompted++
+			return p, nil
+		}
+	}
+
+	k, err := ReadSignKey(strings.NewReader(private), passphrase("secret"))
+	require.NoError(t, err)
+	assert.False(t, k.PrivateKey.Encrypted)
+	assert.Equal(t, 1, prompted)
+
+	_, err = ReadSignKey(strings.NewReader(private), passphrase("wrong"))
+	assert.Error(t, err)
+
+	_, err = ReadSignKey(strings.NewReader(private), func() (string, error) {
+		return "", errors.New("no terminal")
+	})
+	assert.EqualError(t, err, "no terminal")
+
+	_, err = ReadSignKey(strings.NewReader(readTestKey(t, "public.asc")), passphrase(""))
+	assert.Error(t, err)
+}
+
+func TestGitSign(t *testing.T) {
+	stub, restore := stubClone()
+	defer restore()
+
+	key, err := ReadSignKey(strings.NewReader(readTestKey(t, "private.asc")), func() (string, error) {
+		return "secret", nil
+	})
+	require.NoError(t, err)
+	public := readTestKey(t, "public.asc")
+
+	g, err := NewGit(testInfo, "secret", Sign(key))
+	require.NoError(t, err)
+
+	for _, f := range []string{"./testdata/test_1.txt", "./testdata/test_2.txt"} {
+		assert.NoError(t, g.Add(f))
+		hash, err := g.Commit("adding " + f)
+		require.NoError(t, err)
+
+		head, err := stub.repo.Head()
+		require.NoError(t, err)
+		assert.Equal(t, hash, head.Hash().String())
+
+		c, err := stub.repo.CommitObject(plumbing.NewHash(hash))
+		require.NoError(t, err)
+		assert.Contains(t, c.PGPSignature, "-----BEGIN PGP SIGNATURE-----")
+
+		_, err = c.Verify(public)
+		assert.NoError(t, err)
+	}
+
+	status, err := g.worktree.Status()
+	require.NoError(t, err)
+	assert.True(t, status.IsClean())
+}
@@ -0,0 +1,143 @@
+package gist
+
+import (
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/kevinburke/ssh_config"
+	homedir "github.com/mitchellh/go-homedir"
+	"github.com/pkg/errors"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+	"gopkg.in/src-d/go-git.v4/plumbing/transport"
+	gitssh "gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+// SSHOptions configures the SSH transport, see SSH.
+type SSHOptions struct {
+	// Host replaces the host of the gist's git URL, e.g. with a Host alias
+	// of ~/.ssh/config.
+	Host string
+
+	// User defaults to the User of the host in ~/.ssh/config, else git.
+	User string
+
+	// KeyFile is the private key to authenticate with. ssh-agent is used
+	// when it is empty, falling back to the IdentityFile of the host in
+	// ~/.ssh/config.
+	KeyFile    string
+	Passphrase string
+
+	// KnownHosts are the files to verify the host key with, by default
+	// ~/.ssh/known_hosts and /etc/ssh/ssh_known_hosts.
+	KnownHosts []string
+}
+
+// SSH clones and pushes the gist over SSH, e.g. git@gist.github.com:abc.git,
+// instead of HTTPS with the access token.
+func SSH(opts SSHOptions) GitOption {
+	return func(o *gitOptions) {
+		o.ssh = &opts
+	}
+}
+
+// splitGitURL returns the host and the path of an HTTPS or scp-like git URL.
+func splitGitURL(gitURL string) (string, string, error) {
+	if strings.Contains(gitURL, "://") {
+		u, err := url.Parse(gitURL)
+		if err != nil {
+			return "", "", errors.Wrapf(err, "when parsing git URL %s", gitURL)
+		}
+		return u.Hostname(), strings.TrimPrefix(u.Path, "/"), nil
+	}
+
+	i := strings.LastIndex(gitURL, ":")
+	if i < 0 {
+		return "", "", errors.Errorf("%s is not a git URL", gitURL)
+	}
+
+	host := gitURL[:i]
+	if j := strings.Index(host, "@"); j >= 0 {
+		host = host[j+1:]
+	}
+	return host, gitURL[i+1:], nil
+}
+
+// sshAuth authenticates as user on host, which may be an alias of
+// ~/.ssh/config.
+func sshAuth(host, user string, o *SSHOptions) (transport.AuthMethod, error) {
+	callback, err := knownHostsCallback(o.KnownHosts)
+	if err != nil {
+		return nil, errors.Wrap(err, "when reading known_hosts")
+	}
+
+	keyFile := o.KeyFile
+	if keyFile == "" {
+		a, err := gitssh.NewSSHAgentAuth(user)
+		if err == nil {
+			a.HostKeyCallback = callback
+			return a, nil
+		}
+
+		keyFile = identityFile(host)
+		if keyFile == "" {
+			return nil, errors.Wrap(err, "when connecting to ssh-agent")
+		}
+	}
+
+	path, err := homedir.Expand(keyFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "when expanding %s", keyFile)
+	}
+
+	k, err := gitssh.NewPublicKeysFromFile(user, path, o.Passphrase)
+	if err != nil {
+		return nil, errors.Wrapf(err, "when reading SSH key %s", path)
+	}
+	k.HostKeyCallback = callback
+	return k, nil
+}
+
+// knownHostsCallback verifies host keys with files, or with the default
+// known_hosts files when files is empty.
+func knownHostsCallback(files []string) (ssh.HostKeyCallback, error) {
+	if len(files) == 0 {
+		return gitssh.NewKnownHostsCallback()
+	}
+
+	paths := make([]string, len(files))
+	for i, f := range files {
+		p, err := homedir.Expand(f)
+		if err != nil {
+			return nil, errors.Wrapf(err, "when expanding %s", f)
+		}
+		paths[i] = p
+	}
+	return knownhosts.New(paths...)
+}
+
+// sshUser is the User of host in ~/.ssh/config, else git.
+func sshUser(host string) string {
+	if u := ssh_config.Get(host, "User"); u != "" {
+		return u
+	}
+	return "git"
+}
+
+// identityFile is the IdentityFile of host in ~/.ssh/config when it exists.
+func identityFile(host string) string {
+	f := ssh_config.Get(host, "IdentityFile")
+	if f == "" {
+		return ""
+	}
+
+	path, err := homedir.Expand(f)
+	if err != nil {
+		return ""
+	}
+	if _, err := os.Stat(path); err != nil {
+		return ""
+	}
+	return f
+}
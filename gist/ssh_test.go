@@ -0,0 +1,64 @@
+package gist
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	gitssh "gopkg.in/src-d/go-git.v4/plumbing/transport/ssh"
+)
+
+func TestSplitGitURL(t *testing.T) {
+	for _, tc := range []struct {
+		gitURL string
+		host   string
+		path   string
+	}{
+		{"https://gist.github.com/abc123.git", "gist.github.com", "abc123.git"},
+		{"https://github.example.com/gist/abc123.git", "github.example.com", "gist/abc123.git"},
+		{"git@gist.github.com:abc123.git", "gist.github.com", "abc123.git"},
+		{"gist-work:abc123.git", "gist-work", "abc123.git"},
+	} {
+		host, path, err := splitGitURL(tc.gitURL)
+		assert.NoError(t, err, tc.gitURL)
+		assert.Equal(t, tc.host, host, tc.gitURL)
+		assert.Equal(t, tc.path, path, tc.gitURL)
+	}
+
+	_, _, err := splitGitURL("abc123")
+	assert.Error(t, err)
+}
+
+func TestGitSSH(t *testing.T) {
+	stub, restore := stubClone()
+	defer restore()
+
+	info := testInfo
+	info.GitURL = "https://gist.github.com/abc123.git"
+
+	ssh := SSHOptions{
+		Host:       "gist-work",
+		User:       "git",
+		KeyFile:    "./testdata/ssh/id_rsa",
+		Passphrase: "secret",
+		KnownHosts: []string{"./testdata/ssh/known_hosts"},
+	}
+
+	g, err := NewGit(info, "secret", SSH(ssh))
+	require.NoError(t, err)
+
+	assert.Equal(t, "git@gist-work:abc123.git", stub.url)
+	require.IsType(t, &gitssh.PublicKeys{}, stub.auth)
+	assert.Equal(t, "git", stub.auth.(*gitssh.PublicKeys).User)
+	assert.NotNil(t, stub.auth.(*gitssh.PublicKeys).HostKeyCallback)
+	assert.Equal(t, stub.auth, g.auth)
+
+	ssh.Passphrase = "wrong"
+	_, err = NewGit(info, "secret", SSH(ssh))
+	assert.Error(t, err)
+
+	ssh.Passphrase = "secret"
+	ssh.KnownHosts = []string{"./testdata/ssh/missing"}
+	_, err = NewGit(info, "secret", SSH(ssh))
+	assert.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package gist
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"unicode/utf8"
+
+	"github.com/pkg/errors"
+)
+
+// maxTextSize is the largest file SplitText sends through the API, which
+// truncates larger contents when the gist is read.
+const maxTextSize = 1 << 20
+
+// TextUpload is an upload small enough and valid UTF-8 text, so that it can be
+// created through the API with File instead of being pushed.
+type TextUpload struct {
+	Name    string
+	Content string
+}
+
+// SplitText reads uploads and returns the text ones, and the rest that must be
+// pushed with git. Files found by walking a directory are always pushed so
+// that the manifest stays complete. The content read from a Reader that turns
+// out to be binary is put back in front of it.
+func SplitText(uploads []Upload) ([]TextUpload, []Upload, error) {
+	var (
+		text   []TextUpload
+		binary []Upload
+	)
+
+	for _, u := range uploads {
+		if u.Nested != "" || u.Size > maxTextSize {
+			binary = append(binary, u)
+			continue
+		}
+
+		content, rest, err := sniff(u)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if isText(content) {
+			text = append(text, TextUpload{Name: u.Name, Content: string(content)})
+			continue
+		}
+		binary = append(binary, rest)
+	}
+
+	return text, binary, nil
+}
+
+// sniff reads up to maxTextSize+1 bytes of u. The returned upload reads the
+// same content as u.
+func sniff(u Upload) ([]byte, Upload, error) {
+	r := u.Reader
+	if r == nil {
+		f, err := os.Open(u.Path)
+		if err != nil {
+			return nil, u, errors.Wrapf(err, "when opening %s", u.Path)
+		}
+		defer f.Close()
+		r = f
+	}
+
+	b, err := ioutil.ReadAll(io.LimitReader(r, maxTextSize+1))
+	if err != nil {
+		return nil, u, errors.Wrapf(err, "when reading %s", u.Path)
+	}
+
+	if u.Reader != nil {
+		u.Reader = io.MultiReader(bytes.NewReader(b), u.Reader)
+	}
+	return b, u, nil
+}
+
+// isText reports whether b can be carried by the API: not empty or blank,
+// not too large, valid UTF-8, and without NUL bytes.
+func isText(b []byte) bool {
+	return len(b) <= maxTextSize &&
+		len(bytes.TrimSpace(b)) > 0 &&
+		utf8.Valid(b) &&
+		bytes.IndexByte(b, 0) < 0
+}
@@ -0,0 +1,49 @@
+package gist
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitText(t *testing.T) {
+	binaryContent := []byte("\x00\x01\x02 binary")
+
+	uploads := []Upload{
+		{Name: "test_1.txt", Path: "./testdata/test_1.txt", Size: 17},
+		{Name: "binary.png", Path: "./testdata/binary.png", Size: 16},
+		{Name: "nested__a__test_1.txt", Nested: "nested/a/test_1.txt", Path: "./testdata/nested/a/test_1.txt", Size: 9},
+		ReaderUpload("notes.md", strings.NewReader("# notes\n")),
+		ReaderUpload("blob.bin", bytes.NewReader(binaryContent)),
+	}
+
+	text, binary, err := SplitText(uploads)
+	require.NoError(t, err)
+
+	assert.Equal(t, []TextUpload{
+		{Name: "test_1.txt", Content: "this is a test 1\n"},
+		{Name: "notes.md", Content: "# notes\n"},
+	}, text)
+
+	require.Len(t, binary, 3)
+	assert.Equal(t, uploads[1], binary[0])
+	assert.Equal(t, uploads[2], binary[1])
+	assert.Equal(t, "blob.bin", binary[2].Name)
+
+	b, err := ioutil.ReadAll(binary[2].Reader)
+	assert.NoError(t, err)
+	assert.Equal(t, binaryContent, b)
+}
+
+func TestIsText(t *testing.T) {
+	assert.True(t, isText([]byte("hello, 世界\n")))
+	assert.False(t, isText(nil))
+	assert.False(t, isText([]byte(" \n")))
+	assert.False(t, isText([]byte("a\x00b")))
+	assert.False(t, isText([]byte{0xff, 0xfe}))
+	assert.False(t, isText(bytes.Repeat([]byte("a"), maxTextSize+1)))
+}
@@ -0,0 +1,319 @@
+package gist
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// Uploader creates and updates gists with any kind of file. Text files are
+// sent through the API when a gist is created, the other files are pushed
+// with git, which the API cannot carry. It should be created with
+// NewUploader.
+type Uploader struct {
+	client      *Client
+	accessToken string
+
+	// GitOptions configure the clone, e.g. OnDisk, SSH, Author, or Sign.
+	GitOptions []GitOption
+
+	// Storage, when set, returns the options that choose where the clone is
+	// kept, e.g. OnDisk, for the size of the gist with the uploads. The size
+	// is -1 when it is unknown because of a reader.
+	Storage func(size int64) ([]GitOption, error)
+
+	// Retry is the policy for failed pushes, DefaultRetryPolicy unless
+	// changed.
+	Retry RetryPolicy
+
+	// Progress, when set, is informed while files are copied and pushed.
+	Progress Progress
+
+	// Message returns the commit message of the uploads of a commit,
+	// DefaultMessage when nil.
+	Message func([]Upload) (string, error)
+
+	// CommitPerFile makes a separate commit for every upload.
+	CommitPerFile bool
+
+	// Identity, when set, is the user, e.g. from Client.WhoAmI, the author
+	// is resolved for with Client.Author, unless the Author option is
+	// given. The owner of the gist is the author otherwise.
+	Identity *Identity
+
+	// KeepOnFailure keeps the gist made by Create when the push fails,
+	// instead of deleting it.
+	KeepOnFailure bool
+}
+
+// NewUploader creates an Uploader that calls the API with client and pushes
+// with accessToken.
+func NewUploader(client *Client, accessToken string) *Uploader {
+	return &Uploader{
+		client:      client,
+		accessToken: accessToken,
+		Retry:       DefaultRetryPolicy,
+	}
+}
+
+// Result of Create and Update.
+type Result struct {
+	// Info of the gist, with the raw URLs pinned to the last commit.
+	Info
+
+	// Commits pushed, oldest first. It is empty when Create sent every
+	// file through the API.
+	Commits []string
+
+	// Uploads are the files that were uploaded.
+	Uploads []Upload
+}
+
+// IncompleteError is returned by Create when the gist was created but the
+// push failed.
+type IncompleteError struct {
+	Info Info
+	Err  error
+
+	// Deleted reports whether the gist was deleted again. DeleteErr is why
+	// it was not, unless KeepOnFailure is set.
+	Deleted   bool
+	DeleteErr error
+}
+
+func (e *IncompleteError) Error() string {
+	return e.Err.Error()
+}
+
+// Cause returns the error of the push.
+func (e *IncompleteError) Cause() error {
+	return e.Err
+}
+
+// ErrNoUploads is returned by Create and Update when there is nothing to
+// upload.
+var ErrNoUploads = errors.New("no files to upload")
+
+// DefaultMessage is "Add " followed by the gist filenames of uploads.
+func DefaultMessage(uploads []Upload) (string, error) {
+	names := make([]string, 0, len(uploads))
+	for _, u := range uploads {
+		names = append(names, u.Name)
+	}
+	return fmt.Sprintf("Add %s", strings.Join(names, ", ")), nil
+}
+
+// Create creates a gist with uploads, e.g. prepared with Plan, and ops such
+// as Description and Public. A gist cannot be created without files, so when
+// every upload must be pushed, a placeholder is created and removed by the
+// push. If the push fails, the gist is deleted unless KeepOnFailure is set,
+// and an *IncompleteError is returned.
+func (u *Uploader) Create(ctx context.Context, uploads []Upload, ops ...Option) (Result, error) {
+	if len(uploads) == 0 {
+		return Result{}, ErrNoUploads
+	}
+
+	uploads, err := checkReaders(uploads)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Files created through the API cannot be signed, so everything is
+	// pushed when signing.
+	text, binary := []TextUpload(nil), uploads
+	if u.options().signKey == nil {
+		text, binary, err = SplitText(uploads)
+		if err != nil {
+			return Result{}, err
+		}
+	}
+
+	for _, t := range text {
+		t := t
+		ops = append(ops, File(&github.GistFile{Filename: &t.Name, Content: &t.Content}))
+	}
+
+	var remove []string
+	if len(text) == 0 {
+		ops = append(ops, File(&github.GistFile{Filename: &tmpFilename, Content: &tmpContent}))
+		remove = append(remove, tmpFilename)
+	}
+
+	info, err := u.client.CreateGist(ctx, ops...)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if len(binary) == 0 {
+		return Result{Info: info, Uploads: uploads}, nil
+	}
+
+	commits, err := u.push(ctx, info, binary, remove...)
+	if err != nil {
+		return Result{}, u.rollback(ctx, info, err)
+	}
+
+	return u.result(ctx, info.ID, commits, uploads)
+}
+
+// Update pushes uploads to the existing gist id.
+func (u *Uploader) Update(ctx context.Context, id string, uploads []Upload) (Result, error) {
+	if len(uploads) == 0 {
+		return Result{}, ErrNoUploads
+	}
+
+	uploads, err := checkReaders(uploads)
+	if err != nil {
+		return Result{}, err
+	}
+
+	info, err := u.client.GetGist(ctx, id)
+	if err != nil {
+		return Result{}, err
+	}
+
+	commits, err := u.push(ctx, info, uploads)
+	if err != nil {
+		return Result{}, err
+	}
+
+	return u.result(ctx, id, commits, uploads)
+}
+
+func (u *Uploader) options() gitOptions {
+	var o gitOptions
+	for _, op := range u.GitOptions {
+		op(&o)
+	}
+	return o
+}
+
+// push clones the gist, removes the given files, adds the uploads, then
+// commits, once or per file, and pushes the result.
+func (u *Uploader) push(ctx context.Context, info Info, uploads []Upload, remove ...string) ([]string, error) {
+	ops := u.GitOptions[:len(u.GitOptions):len(u.GitOptions)]
+	if u.Storage != nil {
+		storage, err := u.Storage(cloneSize(info, uploads))
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, storage...)
+	}
+
+	if u.Identity != nil && u.options().author == nil {
+		p, err := u.client.Author(ctx, *u.Identity)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, Author(p))
+	}
+
+	g, err := NewGit(info, u.accessToken, ops...)
+	if err != nil {
+		return nil, err
+	}
+	defer g.Close()
+
+	g.Retry = u.Retry
+	g.Progress = u.Progress
+
+	for _, f := range remove {
+		if err := g.Remove(f); err != nil {
+			return nil, err
+		}
+	}
+
+	message := u.Message
+	if message == nil {
+		message = DefaultMessage
+	}
+
+	batches := [][]Upload{uploads}
+	if u.CommitPerFile {
+		batches = batches[:0]
+		for _, up := range uploads {
+			batches = append(batches, []Upload{up})
+		}
+	}
+
+	var commits []string
+	for _, batch := range batches {
+		for _, up := range batch {
+			if err := g.AddUpload(up); err != nil {
+				return nil, err
+			}
+		}
+
+		msg, err := message(batch)
+		if err != nil {
+			return nil, err
+		}
+
+		commit, err := g.Commit(msg)
+		if err != nil {
+			return nil, err
+		}
+		commits = append(commits, commit)
+	}
+
+	if err := g.Push(); err != nil {
+		return nil, err
+	}
+
+	return commits, nil
+}
+
+// cloneSize is the size of the files of the gist and of uploads, -1 when a
+// reader is included.
+func cloneSize(info Info, uploads []Upload) int64 {
+	var total int64
+	for _, f := range info.Files {
+		total += int64(f.Size)
+	}
+
+	for _, u := range uploads {
+		if u.Reader != nil {
+			return -1
+		}
+		total += u.Size
+	}
+	return total
+}
+
+// result refreshes the gist after the push, which changed its files.
+func (u *Uploader) result(ctx context.Context, id string, commits []string, uploads []Upload) (Result, error) {
+	info, err := u.client.GetGist(ctx, id)
+	if err != nil {
+		return Result{}, err
+	}
+
+	if len(commits) > 0 {
+		info = info.Pin(commits[len(commits)-1])
+	}
+
+	return Result{
+		Info:    info,
+		Commits: commits,
+		Uploads: uploads,
+	}, nil
+}
+
+// rollback deletes the gist made by Create after cause made the push fail,
+// unless KeepOnFailure is set.
+func (u *Uploader) rollback(ctx context.Context, info Info, cause error) error {
+	e := &IncompleteError{Info: info, Err: cause}
+	if u.KeepOnFailure {
+		return e
+	}
+
+	if err := u.client.DeleteGist(ctx, info.ID); err != nil {
+		e.DeleteErr = errors.Wrap(err, "when deleting the incomplete gist")
+		return e
+	}
+
+	e.Deleted = true
+	return e
+}
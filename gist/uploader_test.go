@@ -0,0 +1,340 @@
+package gist
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	gomock "github.com/golang/mock/gomock"
+	github "github.com/google/go-github/github"
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/openpgp"
+	billy "gopkg.in/src-d/go-billy.v4"
+	git "gopkg.in/src-d/go-git.v4"
+	"gopkg.in/src-d/go-git.v4/plumbing"
+	"gopkg.in/src-d/go-git.v4/plumbing/object"
+)
+
+var testGist = &github.Gist{
+	ID:         &testInfo.ID,
+	HTMLURL:    &testInfo.HTMLURL,
+	GitPullURL: &testInfo.GitURL,
+	Owner: &github.User{
+		Login: &testInfo.Owner.Login,
+		Name:  &testInfo.Owner.Name,
+		Email: &testInfo.Owner.Email,
+	},
+}
+
+// addPlaceholder commits the placeholder file the gist is created with.
+func addPlaceholder(w *git.Worktree, f billy.Filesystem) error {
+	file, err := f.Create(tmpFilename)
+	if err != nil {
+		return errors.Wrap(err, "when creating the placeholder")
+	}
+	file.Write([]byte(tmpContent))
+	file.Close()
+
+	if _, err := w.Add(tmpFilename); err != nil {
+		return errors.Wrap(err, "when adding the placeholder")
+	}
+
+	_, err = w.Commit("placeholder", &git.CommitOptions{
+		Author: &object.Signature{Name: testInfo.Owner.Name, Email: testInfo.Owner.Email},
+	})
+	return errors.Wrap(err, "when committing the placeholder")
+}
+
+func TestUploaderCreateText(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockGister := NewMockGister(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = mockGister
+
+	stub, restore := stubClone()
+	defer restore()
+
+	mockGister.EXPECT().Create(ctx, &github.Gist{
+		Description: github.String("notes"),
+		Files: map[github.GistFilename]github.GistFile{
+			"test_1.txt": {
+				Filename: github.String("test_1.txt"),
+				Content:  github.String("this is a test 1\n"),
+			},
+		},
+	}).Return(testGist, nil, nil)
+
+	uploads := []Upload{{Name: "test_1.txt", Path: "./testdata/test_1.txt", Size: 17}}
+
+	res, err := NewUploader(c, "secret").Create(ctx, uploads, Description("notes"))
+	require.NoError(t, err)
+	assert.Equal(t, testInfo, res.Info)
+	assert.Empty(t, res.Commits)
+	assert.Nil(t, stub.repo, "text files must not be pushed")
+	assert.Equal(t, uploads, res.Uploads)
+}
+
+func TestUploaderCreateBinary(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockGister := NewMockGister(mockCtrl)
+	mockRepoer := NewMockrepoer(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = mockGister
+
+	stub, restore := stubClone()
+	defer restore()
+	stub.pusher = mockRepoer
+	stub.setup = addPlaceholder
+
+	uploads := []Upload{
+		{Name: "binary.png", Path: "./testdata/binary.png", Size: 16},
+		ReaderUpload("blob.bin", bytes.NewReader([]byte("\x00\x01"))),
+	}
+
+	gomock.InOrder(
+		mockGister.EXPECT().Create(ctx, &github.Gist{
+			Files: map[github.GistFilename]github.GistFile{
+				github.GistFilename(tmpFilename): {Filename: &tmpFilename, Content: &tmpContent},
+			},
+		}).Return(testGist, nil, nil),
+		mockRepoer.EXPECT().Push(gomock.Any()).Return(nil),
+		mockGister.EXPECT().Get(ctx, testInfo.ID).Return(testGist, nil, nil),
+	)
+
+	u := NewUploader(c, "secret")
+	u.CommitPerFile = true
+	var messages []string
+	u.Message = func(uploads []Upload) (string, error) {
+		msg, err := DefaultMessage(uploads)
+		messages = append(messages, msg)
+		return msg, err
+	}
+
+	res, err := u.Create(ctx, uploads)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Add binary.png", "Add blob.bin"}, messages)
+	require.Len(t, res.Commits, 2)
+	assert.Equal(t, res.Commits[1], res.Info.Commit)
+}
+
+func TestUploaderCreateFailure(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockGister := NewMockGister(mockCtrl)
+	mockRepoer := NewMockrepoer(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = mockGister
+
+	stub, restore := stubClone()
+	defer restore()
+	stub.pusher = mockRepoer
+	stub.setup = addPlaceholder
+
+	uploads := []Upload{{Name: "binary.png", Path: "./testdata/binary.png", Size: 16}}
+	pushErr := errors.New("push failed")
+
+	for _, keep := range []bool{false, true} {
+		mockGister.EXPECT().Create(ctx, gomock.Any()).Return(testGist, nil, nil)
+		mockRepoer.EXPECT().Push(gomock.Any()).Return(pushErr)
+		if !keep {
+			mockGister.EXPECT().Delete(ctx, testInfo.ID).Return(nil, nil)
+		}
+
+		u := NewUploader(c, "secret")
+		u.Retry = RetryPolicy{Attempts: 1}
+		u.KeepOnFailure = keep
+
+		_, err := u.Create(ctx, uploads)
+		e, ok := err.(*IncompleteError)
+		require.True(t, ok, "%v", err)
+		assert.Equal(t, testInfo.ID, e.Info.ID)
+		assert.Equal(t, pushErr, errors.Cause(e))
+		assert.Equal(t, !keep, e.Deleted)
+		assert.NoError(t, e.DeleteErr)
+	}
+}
+
+func TestDefaultMessage(t *testing.T) {
+	msg, err := DefaultMessage([]Upload{{Name: "a.png"}, {Name: "b.png"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "Add a.png, b.png", msg)
+}
+
+func TestUploaderUpdate(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockGister := NewMockGister(mockCtrl)
+	mockRepoer := NewMockrepoer(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = mockGister
+
+	stub, restore := stubClone()
+	defer restore()
+	stub.pusher = mockRepoer
+	stub.setup = addPlaceholder
+
+	uploads := []Upload{
+		{Name: "test_1.txt", Path: "./testdata/test_1.txt", Size: 17},
+		{Name: "binary.png", Path: "./testdata/binary.png", Size: 16},
+	}
+
+	gomock.InOrder(
+		mockGister.EXPECT().Get(ctx, testInfo.ID).Return(testGist, nil, nil),
+		mockRepoer.EXPECT().Push(gomock.Any()).Return(nil),
+		mockGister.EXPECT().Get(ctx, testInfo.ID).Return(testGist, nil, nil),
+	)
+
+	res, err := NewUploader(c, "secret").Update(ctx, testInfo.ID, uploads)
+	require.NoError(t, err)
+	require.Len(t, res.Commits, 1)
+	assert.Equal(t, res.Commits[0], res.Info.Commit)
+	assert.Equal(t, uploads, res.Uploads)
+
+	// A failed push leaves the existing gist alone.
+	gomock.InOrder(
+		mockGister.EXPECT().Get(ctx, testInfo.ID).Return(testGist, nil, nil),
+		mockRepoer.EXPECT().Push(gomock.Any()).Return(errors.New("push failed")),
+	)
+
+	u := NewUploader(c, "secret")
+	u.Retry = RetryPolicy{Attempts: 1}
+	_, err = u.Update(ctx, testInfo.ID, uploads)
+	assert.EqualError(t, err, "when pushing: push failed")
+
+	mockGister.EXPECT().Get(ctx, "unknown").Return(nil, nil, newErrorResponse(http.StatusNotFound))
+	_, err = u.Update(ctx, "unknown", uploads)
+	assert.Error(t, err)
+}
+
+func TestUploaderNoUploads(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// No call is expected on the API.
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = NewMockGister(mockCtrl)
+
+	u := NewUploader(c, "secret")
+	u.CommitPerFile = true
+
+	_, err := u.Create(ctx, nil)
+	assert.Equal(t, ErrNoUploads, err)
+
+	_, err = u.Update(ctx, testInfo.ID, []Upload{})
+	assert.Equal(t, ErrNoUploads, err)
+}
+
+func TestUploaderEmptyReader(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	// The gist is not created when a reader turns out to be empty.
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = NewMockGister(mockCtrl)
+
+	u := NewUploader(c, "secret")
+	u.GitOptions = []GitOption{Sign(&openpgp.Entity{})}
+
+	uploads := []Upload{ReaderUpload("e.bin", bytes.NewReader(nil))}
+
+	_, err := u.Create(ctx, uploads)
+	assert.IsType(t, &ValidationError{}, err)
+
+	_, err = u.Update(ctx, testInfo.ID, uploads)
+	assert.IsType(t, &ValidationError{}, err)
+}
+
+func TestUploaderStorage(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockGister := NewMockGister(mockCtrl)
+	mockRepoer := NewMockrepoer(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = mockGister
+
+	stub, restore := stubClone()
+	defer restore()
+	stub.pusher = mockRepoer
+
+	large := *testGist
+	large.Files = map[github.GistFilename]github.GistFile{
+		"a.png": {Size: github.Int(10 << 20)},
+		"b.png": {Size: github.Int(20 << 20)},
+	}
+
+	mockGister.EXPECT().Get(ctx, testInfo.ID).Return(&large, nil, nil).Times(2)
+	mockRepoer.EXPECT().Push(gomock.Any()).Return(nil)
+
+	// The existing files count, not only the uploads.
+	var sizes []int64
+	u := NewUploader(c, "secret")
+	u.Storage = func(size int64) ([]GitOption, error) {
+		sizes = append(sizes, size)
+		return nil, nil
+	}
+
+	_, err := u.Update(ctx, testInfo.ID, []Upload{{Name: "binary.png", Path: "./testdata/binary.png", Size: 16}})
+	require.NoError(t, err)
+	assert.Equal(t, []int64{30<<20 + 16}, sizes)
+
+	assert.Equal(t, int64(-1), cloneSize(testInfo, []Upload{ReaderUpload("e.bin", bytes.NewReader(nil))}))
+}
+
+func TestUploaderIdentity(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockGister := NewMockGister(mockCtrl)
+	mockUserer := NewMockuserer(mockCtrl)
+	mockRepoer := NewMockrepoer(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.gist = mockGister
+	c.users = mockUserer
+
+	stub, restore := stubClone()
+	defer restore()
+	stub.pusher = mockRepoer
+
+	// Only the emails are read, the user is already known.
+	gomock.InOrder(
+		mockGister.EXPECT().Get(ctx, testInfo.ID).Return(testGist, nil, nil),
+		mockUserer.EXPECT().ListEmails(ctx, gomock.Any()).Return(nil, nil, newErrorResponse(http.StatusForbidden)),
+		mockRepoer.EXPECT().Push(gomock.Any()).Return(nil),
+		mockGister.EXPECT().Get(ctx, testInfo.ID).Return(testGist, nil, nil),
+	)
+
+	u := NewUploader(c, "secret")
+	u.Identity = &Identity{UserID: 42, Login: "johndoe"}
+
+	res, err := u.Update(ctx, testInfo.ID, []Upload{{Name: "binary.png", Path: "./testdata/binary.png", Size: 16}})
+	require.NoError(t, err)
+
+	commit, err := stub.repo.CommitObject(plumbing.NewHash(res.Commits[0]))
+	require.NoError(t, err)
+	assert.Equal(t, "johndoe", commit.Author.Name)
+	assert.Equal(t, "42+johndoe@users.noreply.github.com", commit.Author.Email)
+}
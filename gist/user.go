@@ -0,0 +1,118 @@
+//go:generate mockgen -source=user.go -destination=mock_user_test.go -package=gist
+package gist
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/go-github/github"
+	"github.com/pkg/errors"
+)
+
+// ScopeGist is the OAuth scope needed to create and change gists.
+const ScopeGist = "gist"
+
+type userer interface {
+	Get(context.Context, string) (*github.User, *github.Response, error)
+	ListEmails(context.Context, *github.ListOptions) ([]*github.UserEmail, *github.Response, error)
+}
+
+// Identity is the GitHub user the access token belongs to.
+type Identity struct {
+	UserID int64
+	Login  string
+	Name   string
+	Email  string
+
+	// Scopes are the OAuth scopes of the token, nil when GitHub does not
+	// report them, e.g. for fine-grained tokens.
+	Scopes []string
+
+	RateLimit     int
+	RateRemaining int
+	RateReset     time.Time
+}
+
+// ScopeError is returned by Identity.RequireScope when the token lacks a
+// scope.
+type ScopeError struct {
+	Scope  string
+	Scopes []string
+}
+
+func (e *ScopeError) Error() string {
+	has := strings.Join(e.Scopes, ", ")
+	if has == "" {
+		has = "none"
+	}
+	return fmt.Sprintf("the access token does not have the %s scope (it has: %s)", e.Scope, has)
+}
+
+// RequireScope returns a *ScopeError unless the token has scope. Tokens whose
+// scopes are not reported pass.
+func (i Identity) RequireScope(scope string) error {
+	if i.Scopes == nil {
+		return nil
+	}
+
+	for _, s := range i.Scopes {
+		if s == scope {
+			return nil
+		}
+	}
+	return &ScopeError{Scope: scope, Scopes: i.Scopes}
+}
+
+// WhoAmI retrieves the user, the scopes, and the rate limit of the access
+// token.
+func (c *Client) WhoAmI(ctx context.Context) (Identity, error) {
+	var (
+		user *github.User
+		resp *github.Response
+	)
+
+	err := c.Retry.run(ctx, func(int) error {
+		var err error
+		user, resp, err = c.users.Get(ctx, "")
+		return err
+	}, classifyAPI(true))
+	if err != nil {
+		return Identity{}, errors.Wrap(err, "when getting the authenticated user")
+	}
+
+	i := Identity{
+		UserID: user.GetID(),
+		Login:  user.GetLogin(),
+		Name:   user.GetName(),
+		Email:  user.GetEmail(),
+	}
+
+	if resp != nil {
+		i.Scopes = parseScopes(resp.Header["X-Oauth-Scopes"])
+		i.RateLimit = resp.Rate.Limit
+		i.RateRemaining = resp.Rate.Remaining
+		i.RateReset = resp.Rate.Reset.Time
+	}
+
+	return i, nil
+}
+
+// parseScopes splits the X-OAuth-Scopes header, which is missing for tokens
+// without classic scopes.
+func parseScopes(header []string) []string {
+	if header == nil {
+		return nil
+	}
+
+	scopes := []string{}
+	for _, h := range header {
+		for _, s := range strings.Split(h, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				scopes = append(scopes, s)
+			}
+		}
+	}
+	return scopes
+}
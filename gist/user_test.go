@@ -0,0 +1,64 @@
+package gist
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	github "github.com/google/go-github/github"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWhoAmI(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	mockUserer := NewMockuserer(mockCtrl)
+
+	ctx := context.Background()
+	c := NewClient(ctx, "")
+	c.users = mockUserer
+
+	reset := time.Date(2018, 10, 1, 12, 0, 0, 0, time.UTC)
+	header := make(http.Header)
+	header.Set("X-OAuth-Scopes", "gist, repo")
+
+	mockUserer.EXPECT().Get(ctx, "").Return(&github.User{
+		Login: github.String("johndoe"),
+		Name:  github.String("John Doe"),
+	}, &github.Response{
+		Response: &http.Response{Header: header},
+		Rate:     github.Rate{Limit: 5000, Remaining: 4990, Reset: github.Timestamp{Time: reset}},
+	}, nil)
+
+	i, err := c.WhoAmI(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, Identity{
+		Login:         "johndoe",
+		Name:          "John Doe",
+		Scopes:        []string{"gist", "repo"},
+		RateLimit:     5000,
+		RateRemaining: 4990,
+		RateReset:     reset,
+	}, i)
+	assert.NoError(t, i.RequireScope(ScopeGist))
+
+	mockUserer.EXPECT().Get(ctx, "").Return(nil, nil, newErrorResponse(http.StatusUnauthorized))
+
+	_, err = c.WhoAmI(ctx)
+	assert.Error(t, err)
+}
+
+func TestIdentityRequireScope(t *testing.T) {
+	assert.NoError(t, Identity{}.RequireScope(ScopeGist))
+
+	err := Identity{Scopes: []string{}}.RequireScope(ScopeGist)
+	assert.EqualError(t, err, "the access token does not have the gist scope (it has: none)")
+
+	err = Identity{Scopes: []string{"repo", "user"}}.RequireScope(ScopeGist)
+	assert.IsType(t, &ScopeError{}, err)
+	assert.EqualError(t, err, "the access token does not have the gist scope (it has: repo, user)")
+}